@@ -0,0 +1,76 @@
+package resvg
+
+import (
+	"context"
+	"image"
+	"runtime"
+	"sync"
+)
+
+// Job describes a single document to render in a batch
+type Job struct {
+	Data []byte
+
+	// Width and Height are the dimensions of the output. If both are 0, the document is rendered at its
+	// natural size. Otherwise, it is scaled to the output according to Fit.
+	Width, Height uint32
+	Fit           FitOptions
+}
+
+// Result is the outcome of rendering a Job
+type Result struct {
+	Image *image.RGBA
+	Err   error
+}
+
+// RenderBatch renders jobs in parallel, using at most concurrency goroutines, and returns the results
+// in the same order as jobs. If concurrency is 0 or less, runtime.NumCPU() is used. Once ctx is done,
+// jobs that haven't started yet fail with ctx.Err().
+func (r *Renderer) RenderBatch(ctx context.Context, jobs []Job, concurrency int) []Result {
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+	if concurrency > len(jobs) {
+		concurrency = len(jobs)
+	}
+
+	results := make([]Result, len(jobs))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				if err := ctx.Err(); err != nil {
+					results[index] = Result{Err: err}
+					continue
+				}
+				img, err := r.renderJob(jobs[index])
+				results[index] = Result{Image: img, Err: err}
+			}
+		}()
+	}
+
+	for index := range jobs {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+func (r *Renderer) renderJob(job Job) (*image.RGBA, error) {
+	if job.Width == 0 && job.Height == 0 {
+		return r.Render(job.Data)
+	}
+	return r.RenderFit(job.Data, job.Width, job.Height, job.Fit)
+}
+
+// RenderBatch renders jobs in parallel with the default renderer, using at most concurrency goroutines,
+// and returns the results in the same order as jobs. See Renderer.RenderBatch.
+func RenderBatch(ctx context.Context, jobs []Job, concurrency int) []Result {
+	return defaultRenderer.RenderBatch(ctx, jobs, concurrency)
+}
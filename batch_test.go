@@ -0,0 +1,157 @@
+package resvg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image/color"
+	"sync"
+	"testing"
+)
+
+func TestRenderBatch(t *testing.T) {
+	jobs := make([]Job, 20)
+	for i := range jobs {
+		jobs[i] = Job{
+			Data: []byte(fmt.Sprintf(`<svg width="%d" height="10" xmlns="http://www.w3.org/2000/svg">
+				<rect width="100%%" height="100%%" fill="red"/>
+			</svg>`, i+1)),
+		}
+	}
+	jobs[5] = Job{Data: []byte("not svg")}
+	jobs[7].Width, jobs[7].Height = 30, 40
+
+	results := RenderBatch(context.Background(), jobs, 4)
+	if len(results) != len(jobs) {
+		t.Fatalf("Expected %d results, got %d", len(jobs), len(results))
+	}
+
+	for i, result := range results {
+		switch i {
+		case 5:
+			if !errors.Is(result.Err, ErrParsingFailed) {
+				t.Fatalf("Job %d: expected ErrParsingFailed, got %v", i, result.Err)
+			}
+		case 7:
+			if result.Err != nil {
+				t.Fatalf("Job %d failed: %v", i, result.Err)
+			}
+			if bounds := result.Image.Bounds(); bounds.Dx() != 30 || bounds.Dy() != 40 {
+				t.Fatalf("Job %d: expected 30x40 image, got %dx%d", i, bounds.Dx(), bounds.Dy())
+			}
+		default:
+			if result.Err != nil {
+				t.Fatalf("Job %d failed: %v", i, result.Err)
+			}
+			// The width identifies which job the result belongs to
+			if bounds := result.Image.Bounds(); bounds.Dx() != i+1 || bounds.Dy() != 10 {
+				t.Fatalf("Job %d: expected %dx10 image, got %dx%d", i, i+1, bounds.Dx(), bounds.Dy())
+			}
+		}
+	}
+}
+
+func TestRenderBatchCancelled(t *testing.T) {
+	jobs := []Job{
+		{Data: []byte(`<svg width="10" height="10" xmlns="http://www.w3.org/2000/svg"/>`)},
+		{Data: []byte(`<svg width="10" height="10" xmlns="http://www.w3.org/2000/svg"/>`)},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for i, result := range RenderBatch(ctx, jobs, 0) {
+		if !errors.Is(result.Err, context.Canceled) {
+			t.Fatalf("Job %d: expected context.Canceled, got %v", i, result.Err)
+		}
+	}
+}
+
+func TestConcurrentParseSharedOptions(t *testing.T) {
+	svgData := []byte(`<svg width="10" height="10" xmlns="http://www.w3.org/2000/svg">
+		<rect width="10" height="10" fill="red"/>
+	</svg>`)
+
+	opts := NewOptions()
+	defer opts.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				tree, err := ParseFromData(svgData, opts)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if _, err := tree.Render(IdentityTransform(), 10, 10); err != nil {
+					errs <- err
+				}
+				tree.Close()
+			}
+		}()
+	}
+
+	// Changing settings while parses are in progress should be safe
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 10; j++ {
+			opts.SetDPI(float32(96 + j))
+			opts.SetMaxPixels(uint64(1000 + j))
+		}
+	}()
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("Concurrent parse failed: %v", err)
+	}
+}
+
+func TestConcurrentRenderSameTree(t *testing.T) {
+	svgData := []byte(`<svg width="10" height="10" xmlns="http://www.w3.org/2000/svg">
+		<rect width="10" height="10" fill="red"/>
+	</svg>`)
+
+	opts := NewOptions()
+	defer opts.Close()
+
+	tree, err := ParseFromData(svgData, opts)
+	if err != nil {
+		t.Fatalf("ParseFromData failed: %v", err)
+	}
+	defer tree.Close()
+
+	red := color.RGBA{255, 0, 0, 255}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			size := uint32(10 + i)
+			img, err := tree.RenderFit(size, size, FitOptions{Mode: FitFill})
+			if err != nil {
+				errs <- err
+				return
+			}
+			if got := img.RGBAAt(int(size)/2, int(size)/2); got != red {
+				errs <- fmt.Errorf("pixel incorrect: %v", got)
+			}
+			if _, _, err := tree.GetImageBBox(); err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("Concurrent render failed: %v", err)
+	}
+}
@@ -0,0 +1,24 @@
+// Command resvg-worker is the worker process used by the sandbox package. It reads render requests
+// from stdin and writes the results to stdout, and is not meant to be run directly.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/thatoddmailbox/go-resvg"
+	"github.com/thatoddmailbox/go-resvg/sandbox"
+)
+
+func main() {
+	maxPixels := flag.Uint64("max-pixels", 0, "maximum number of pixels in a rendered image (0 for no limit)")
+	flag.Parse()
+
+	resvg.SetMaxPixels(*maxPixels)
+
+	if err := sandbox.Serve(os.Stdin, os.Stdout, nil); err != nil {
+		fmt.Fprintf(os.Stderr, "resvg-worker: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,79 @@
+package resvg
+
+import (
+	"context"
+	"image"
+)
+
+// ParseContext parses SVG data into a render tree like ParseFromData, but returns ctx.Err() as soon as
+// ctx is done. Parsing can't be interrupted once it has started, so it keeps running in the background
+// and the resulting tree is freed when it finishes. data must not be modified after the call. If opts has
+// a ContextResolver, it's passed ctx, so that loading external images stops when ctx is done.
+func ParseContext(ctx context.Context, data []byte, opts *Options) (*RenderTree, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Hold on to the options until the parse finishes, even if the caller closes them after giving up
+	if err := opts.acquire(); err != nil {
+		return nil, err
+	}
+
+	type parseResult struct {
+		tree *RenderTree
+		err  error
+	}
+
+	results := make(chan parseResult, 1)
+	go func() {
+		defer opts.release()
+		tree, err := parseTree(ctx, data, opts, "", "", nil)
+		results <- parseResult{tree, err}
+	}()
+
+	select {
+	case result := <-results:
+		return result.tree, result.err
+	case <-ctx.Done():
+		go func() {
+			if result := <-results; result.tree != nil {
+				result.tree.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+// RenderContext renders the SVG tree to an RGBA image like Render, but returns ctx.Err() as soon as
+// ctx is done. Rendering can't be interrupted once it has started, so it keeps running in the
+// background and its result is discarded. Closing the tree in the meantime is safe; its memory is
+// freed once the render finishes.
+func (t *RenderTree) RenderContext(ctx context.Context, transform Transform, width, height uint32) (*image.RGBA, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Hold on to the tree until the render finishes, even if the caller closes it after giving up
+	if err := t.acquire(); err != nil {
+		return nil, err
+	}
+
+	type renderResult struct {
+		img *image.RGBA
+		err error
+	}
+
+	results := make(chan renderResult, 1)
+	go func() {
+		defer t.release()
+		img, err := t.Render(transform, width, height)
+		results <- renderResult{img, err}
+	}()
+
+	select {
+	case result := <-results:
+		return result.img, result.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,169 @@
+package resvg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// slowSVG takes a long time to render because of the large, repeated blurs
+var slowSVG = []byte(`<svg width="4000" height="4000" xmlns="http://www.w3.org/2000/svg">
+	<defs>
+		<filter id="blur" x="-50%" y="-50%" width="200%" height="200%">
+			<feGaussianBlur stdDeviation="400"/>
+			<feGaussianBlur stdDeviation="400"/>
+			<feGaussianBlur stdDeviation="400"/>
+			<feGaussianBlur stdDeviation="400"/>
+		</filter>
+	</defs>
+	<g filter="url(#blur)"><g filter="url(#blur)"><g filter="url(#blur)"><g filter="url(#blur)">
+		<rect width="4000" height="4000" fill="red"/>
+		<circle cx="2000" cy="2000" r="1500" fill="blue"/>
+	</g></g></g></g>
+</svg>`)
+
+// slowParseSVG takes a long time to parse because of the number of elements
+func slowParseSVG() []byte {
+	var b strings.Builder
+	b.WriteString(`<svg width="1000" height="1000" xmlns="http://www.w3.org/2000/svg">`)
+	for i := 0; i < 200000; i++ {
+		fmt.Fprintf(&b, `<path d="M%d 0 Q 50 %d 100 100 T 200 0 Z" fill="#%06x" transform="rotate(%d 500 500)"/>`, i%1000, i%700, i*37%0xffffff, i%360)
+	}
+	b.WriteString(`</svg>`)
+	return []byte(b.String())
+}
+
+func TestParseContext(t *testing.T) {
+	opts := NewOptions()
+	defer opts.Close()
+
+	tree, err := ParseContext(context.Background(), slowSVG, opts)
+	if err != nil {
+		t.Fatalf("ParseContext failed: %v", err)
+	}
+	tree.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := ParseContext(ctx, slowSVG, opts); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestParseContextAbandoned(t *testing.T) {
+	data := slowParseSVG()
+	goroutines := runtime.NumGoroutine()
+
+	opts := NewOptions()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	tree, err := ParseContext(ctx, data, opts)
+	elapsed := time.Since(start)
+	if err == nil {
+		tree.Close()
+		opts.Close()
+		t.Skip("Parse finished before the deadline")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed > time.Second {
+		t.Fatalf("ParseContext took %v to return after the deadline", elapsed)
+	}
+
+	// Closing the options while the abandoned parse is still running must be safe, and must not free
+	// them until it finishes
+	if err := opts.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := ParseContext(context.Background(), data, opts); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Expected ErrClosed, got %v", err)
+	}
+
+	// Once the parse finishes, the options are released and the background goroutines, which close the
+	// abandoned tree, exit
+	deadline := time.Now().Add(time.Minute)
+	for {
+		opts.mu.Lock()
+		refs := opts.refs
+		opts.mu.Unlock()
+		if refs == 0 && runtime.NumGoroutine() <= goroutines {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Abandoned parse still running: %d references to the options, %d goroutines, expected %d",
+				refs, runtime.NumGoroutine(), goroutines)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRenderContext(t *testing.T) {
+	opts := NewOptions()
+	defer opts.Close()
+
+	tree, err := ParseFromData(slowSVG, opts)
+	if err != nil {
+		t.Fatalf("ParseFromData failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = tree.RenderContext(ctx, IdentityTransform(), 4000, 4000)
+	elapsed := time.Since(start)
+	if err == nil {
+		t.Skip("Render finished before the deadline")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed > time.Second {
+		t.Fatalf("RenderContext took %v to return after the deadline", elapsed)
+	}
+
+	// Closing the tree while the abandoned render is still running must be safe
+	if err := tree.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := tree.RenderContext(context.Background(), IdentityTransform(), 10, 10); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Expected ErrClosed, got %v", err)
+	}
+}
+
+func TestRenderContextCompletes(t *testing.T) {
+	svgData := []byte(`<svg width="10" height="10" xmlns="http://www.w3.org/2000/svg">
+		<rect width="10" height="10" fill="red"/>
+	</svg>`)
+
+	opts := NewOptions()
+	defer opts.Close()
+
+	tree, err := ParseFromData(svgData, opts)
+	if err != nil {
+		t.Fatalf("ParseFromData failed: %v", err)
+	}
+	defer tree.Close()
+
+	img, err := tree.RenderContext(context.Background(), IdentityTransform(), 10, 10)
+	if err != nil {
+		t.Fatalf("RenderContext failed: %v", err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != 10 || bounds.Dy() != 10 {
+		t.Fatalf("Expected 10x10 image, got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := tree.RenderContext(ctx, IdentityTransform(), 10, 10); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package resvg
+
+import "image"
+
+// BoundingBox selects which bounding box of the document cropped rendering uses
+type BoundingBox int
+
+const (
+	// ImageBBox is the bounding box of everything that's drawn, including strokes, markers and filters
+	ImageBBox BoundingBox = iota
+
+	// ObjectBBox is the bounding box of the geometry of the shapes alone
+	ObjectBBox
+)
+
+// RenderCropped renders the SVG tree to an RGBA image that hugs its content, using the image bounding box
+// rather than the natural size for the canvas. padding is added on every side of the bounding box, in
+// document units, and the result is rendered at scale. The image dimensions are rounded up to whole pixels.
+func (t *RenderTree) RenderCropped(padding float32, scale float64) (*image.RGBA, error) {
+	return t.RenderCroppedTo(ImageBBox, padding, scale)
+}
+
+// RenderCroppedTo renders the SVG tree like RenderCropped, but crops to the given bounding box
+func (t *RenderTree) RenderCroppedTo(box BoundingBox, padding float32, scale float64) (*image.RGBA, error) {
+	bbox, err := t.contentBBox(box)
+	if err != nil {
+		return nil, err
+	}
+
+	region := bbox.Outset(padding)
+	width, err := scaleDimension(region.Width, scale)
+	if err != nil {
+		return nil, err
+	}
+	height, err := scaleDimension(region.Height, scale)
+	if err != nil {
+		return nil, err
+	}
+
+	transform := Translate(-region.X, -region.Y).Then(Scale(float32(scale), float32(scale)))
+	return t.Render(transform, width, height)
+}
+
+// contentBBox returns the chosen bounding box of the tree, failing if nothing is drawn
+func (t *RenderTree) contentBBox(box BoundingBox) (Rect, error) {
+	var (
+		bbox Rect
+		ok   bool
+		err  error
+	)
+	if box == ObjectBBox {
+		bbox, ok, err = t.GetObjectBBox()
+	} else {
+		bbox, ok, err = t.GetImageBBox()
+	}
+	if err != nil {
+		return Rect{}, err
+	}
+	if !ok || bbox.Empty() {
+		return Rect{}, &Error{Op: "render", Err: ErrEmptyImage}
+	}
+	return bbox, nil
+}
+
+// RenderTrimmed renders SVG data to an RGBA image at its natural scale, cropped to the image bounding box
+// so that empty margins around the content are trimmed
+func (r *Renderer) RenderTrimmed(data []byte) (*image.RGBA, error) {
+	tree, err := r.parseData(data)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+
+	return tree.RenderCropped(0, 1)
+}
+
+// RenderTrimmed renders SVG data cropped to its content with the default renderer. See Renderer.RenderTrimmed.
+func RenderTrimmed(data []byte) (*image.RGBA, error) {
+	return defaultRenderer.RenderTrimmed(data)
+}
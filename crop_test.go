@@ -0,0 +1,96 @@
+package resvg
+
+import (
+	"errors"
+	"image/color"
+	"testing"
+)
+
+// cropTestSVG has a 10x20 rectangle with a 4 unit stroke in a mostly empty 100x100 canvas, so its object
+// bounding box is (20, 30) 10x20 and its image bounding box is (18, 28) 14x24
+const cropTestSVG = `<svg width="100" height="100" xmlns="http://www.w3.org/2000/svg">
+	<rect x="20" y="30" width="10" height="20" fill="red" stroke="blue" stroke-width="4"/>
+</svg>`
+
+func TestRenderCropped(t *testing.T) {
+	opts := NewOptions()
+	defer opts.Close()
+
+	tree, err := ParseFromData([]byte(cropTestSVG), opts)
+	if err != nil {
+		t.Fatalf("ParseFromData failed: %v", err)
+	}
+	defer tree.Close()
+
+	tests := []struct {
+		name          string
+		box           BoundingBox
+		padding       float32
+		scale         float64
+		width, height int
+	}{
+		{"image bbox", ImageBBox, 0, 1, 14, 24},
+		{"image bbox scaled", ImageBBox, 0, 2, 28, 48},
+		{"image bbox padded", ImageBBox, 3, 1, 20, 30},
+		{"object bbox", ObjectBBox, 0, 1, 10, 20},
+		{"object bbox padded and scaled", ObjectBBox, 5, 0.5, 10, 15},
+	}
+
+	for _, test := range tests {
+		img, err := tree.RenderCroppedTo(test.box, test.padding, test.scale)
+		if err != nil {
+			t.Fatalf("%s: RenderCroppedTo failed: %v", test.name, err)
+		}
+		if img.Bounds().Dx() != test.width || img.Bounds().Dy() != test.height {
+			t.Fatalf("%s: expected %dx%d, got %v", test.name, test.width, test.height, img.Bounds())
+		}
+	}
+
+	// The stroke is at the edge of the image bbox, and the fill in the middle
+	img, err := tree.RenderCropped(0, 1)
+	if err != nil {
+		t.Fatalf("RenderCropped failed: %v", err)
+	}
+	if got := img.RGBAAt(0, 12); got != (color.RGBA{0, 0, 255, 255}) {
+		t.Fatalf("Expected stroke at the left edge, got %v", got)
+	}
+	if got := img.RGBAAt(7, 12); got != (color.RGBA{255, 0, 0, 255}) {
+		t.Fatalf("Expected fill in the middle, got %v", got)
+	}
+
+	// Padding is left transparent
+	img, err = tree.RenderCropped(3, 1)
+	if err != nil {
+		t.Fatalf("RenderCropped failed: %v", err)
+	}
+	if got := img.RGBAAt(1, 1); got.A != 0 {
+		t.Fatalf("Expected transparent padding, got %v", got)
+	}
+
+	if _, err := tree.RenderCropped(0, 0); !errors.Is(err, ErrInvalidSize) {
+		t.Fatalf("Expected ErrInvalidSize for zero scale, got %v", err)
+	}
+}
+
+func TestRenderTrimmed(t *testing.T) {
+	img, err := RenderTrimmed([]byte(cropTestSVG))
+	if err != nil {
+		t.Fatalf("RenderTrimmed failed: %v", err)
+	}
+	if img.Bounds().Dx() != 14 || img.Bounds().Dy() != 24 {
+		t.Fatalf("Expected 14x24, got %v", img.Bounds())
+	}
+
+	opts := NewOptions()
+	defer opts.Close()
+
+	tree, err := ParseFromData([]byte(`<svg width="100" height="100" xmlns="http://www.w3.org/2000/svg"/>`), opts)
+	if err != nil {
+		t.Fatalf("ParseFromData failed: %v", err)
+	}
+	defer tree.Close()
+
+	if _, err := tree.RenderCropped(0, 1); !errors.Is(err, ErrEmptyImage) {
+		t.Fatalf("Expected ErrEmptyImage, got %v", err)
+	}
+}
@@ -0,0 +1,213 @@
+package resvg
+
+import (
+	"bufio"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"io"
+	"math"
+)
+
+// pngStripHeight is the number of rows EncodePNG renders at a time
+const pngStripHeight = 64
+
+// pngSignature starts every PNG file
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+
+// EncodePNG renders the SVG tree, transformed by transform, at width x height and writes it to w as a PNG
+// with straight alpha. The image is rendered in horizontal strips that are compressed as they're
+// rendered, so memory use grows with the width of the image rather than its area. The pixel limit applies to
+// the whole image, and PNG images can be at most 2^31-1 pixels wide and high.
+//
+// Each strip is rendered through the same tree with an offset transform, so shapes join up across strips
+// without seams. Filters such as blurs are clipped to the strip they're rendered in, though, so near the
+// boundary between two strips they may differ from what Render produces.
+func (t *RenderTree) EncodePNG(w io.Writer, transform Transform, width, height uint32) error {
+	if err := t.acquire(); err != nil {
+		return err
+	}
+	defer t.release()
+	if width > math.MaxInt32 || height > math.MaxInt32 {
+		return &Error{Op: "render", Err: fmt.Errorf("%w: %dx%d is too large for a PNG", ErrInvalidSize, width, height)}
+	}
+	if err := t.checkSize(width, height); err != nil {
+		return err
+	}
+
+	enc, err := newPNGEncoder(w, int(width), int(height))
+	if err != nil {
+		return err
+	}
+
+	strip := image.NewNRGBA(image.Rect(0, 0, int(width), int(minUint32(pngStripHeight, height))))
+	for y := 0; y < int(height); y += pngStripHeight {
+		rows := strip.Rect.Dy()
+		if remaining := int(height) - y; remaining < rows {
+			rows = remaining
+		}
+
+		// Render the strip as if it were the window onto rows y to y+rows of the full image
+		sub := strip.SubImage(image.Rect(0, 0, int(width), rows)).(*image.NRGBA)
+		if err := t.RenderInto(sub, transform.Then(Translate(0, -float32(y)))); err != nil {
+			return err
+		}
+		for row := 0; row < rows; row++ {
+			if err := enc.writeRow(sub.Pix[row*sub.Stride : row*sub.Stride+int(width)*4]); err != nil {
+				return err
+			}
+		}
+	}
+	return enc.close()
+}
+
+// pngEncoder writes an 8-bit RGBA PNG one row at a time
+type pngEncoder struct {
+	w    io.Writer
+	buf  *bufio.Writer
+	zw   *zlib.Writer
+	prev []byte
+
+	// filtered holds the current row with each filter type applied, each prefixed with its filter type byte
+	filtered [5][]byte
+}
+
+// newPNGEncoder writes the PNG header to w and returns an encoder for the rows of a width x height image
+func newPNGEncoder(w io.Writer, width, height int) (*pngEncoder, error) {
+	if _, err := w.Write(pngSignature); err != nil {
+		return nil, err
+	}
+
+	var header [13]byte
+	binary.BigEndian.PutUint32(header[0:], uint32(width))
+	binary.BigEndian.PutUint32(header[4:], uint32(height))
+	header[8] = 8  // bit depth
+	header[9] = 6  // color type: RGBA
+	header[10] = 0 // compression method: deflate
+	header[11] = 0 // filter method: adaptive
+	header[12] = 0 // interlace method: none
+	if err := writePNGChunk(w, "IHDR", header[:]); err != nil {
+		return nil, err
+	}
+
+	e := &pngEncoder{w: w, prev: make([]byte, width*4)}
+	for i := range e.filtered {
+		e.filtered[i] = make([]byte, 1+width*4)
+		e.filtered[i][0] = byte(i)
+	}
+
+	// Buffer the compressed rows so that they're written out in IDAT chunks of up to 32 KiB
+	e.buf = bufio.NewWriterSize(idatWriter{w}, 1<<15)
+	e.zw = zlib.NewWriter(e.buf)
+	return e, nil
+}
+
+// writeRow filters and compresses the next row of straight alpha RGBA8888 pixels
+func (e *pngEncoder) writeRow(row []byte) error {
+	if _, err := e.zw.Write(e.filter(row)); err != nil {
+		return err
+	}
+	copy(e.prev, row)
+	return nil
+}
+
+// filter returns row with whichever filter is likely to compress best, picked with the heuristic suggested
+// by the PNG specification: the smallest sum of absolute differences, treating each byte as signed
+func (e *pngEncoder) filter(row []byte) []byte {
+	const bpp = 4
+	prev := e.prev
+	none, sub, up, avg, paeth := e.filtered[0][1:], e.filtered[1][1:], e.filtered[2][1:], e.filtered[3][1:], e.filtered[4][1:]
+
+	copy(none, row)
+	for i := range row {
+		var left, upLeft byte
+		if i >= bpp {
+			left, upLeft = row[i-bpp], prev[i-bpp]
+		}
+		sub[i] = row[i] - left
+		up[i] = row[i] - prev[i]
+		avg[i] = row[i] - byte((int(left)+int(prev[i]))/2)
+		paeth[i] = row[i] - paethPredictor(left, prev[i], upLeft)
+	}
+
+	best, bestSum := 0, -1
+	for i, filtered := range e.filtered {
+		sum := 0
+		for _, b := range filtered[1:] {
+			if b < 128 {
+				sum += int(b)
+			} else {
+				sum += 256 - int(b)
+			}
+		}
+		if bestSum < 0 || sum < bestSum {
+			best, bestSum = i, sum
+		}
+	}
+	return e.filtered[best]
+}
+
+// close flushes the compressed rows and writes the end of the PNG
+func (e *pngEncoder) close() error {
+	if err := e.zw.Close(); err != nil {
+		return err
+	}
+	if err := e.buf.Flush(); err != nil {
+		return err
+	}
+	return writePNGChunk(e.w, "IEND", nil)
+}
+
+// paethPredictor returns whichever of a (left), b (up) and c (up left) is closest to a + b - c
+func paethPredictor(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := absInt(p-int(a)), absInt(p-int(b)), absInt(p-int(c))
+	if pa <= pb && pa <= pc {
+		return a
+	}
+	if pb <= pc {
+		return b
+	}
+	return c
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// idatWriter writes each call to Write as an IDAT chunk
+type idatWriter struct {
+	w io.Writer
+}
+
+func (w idatWriter) Write(p []byte) (int, error) {
+	if err := writePNGChunk(w.w, "IDAT", p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// writePNGChunk writes a chunk of type typ, with its length and CRC, to w
+func writePNGChunk(w io.Writer, typ string, data []byte) error {
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[0:], uint32(len(data)))
+	copy(header[4:], typ)
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+	var footer [4]byte
+	binary.BigEndian.PutUint32(footer[:], crc.Sum32())
+
+	for _, b := range [][]byte{header[:], data, footer[:]} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package resvg
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestEncodePNG(t *testing.T) {
+	opts := NewOptions()
+	defer opts.Close()
+
+	tree, err := ParseFromData([]byte(tileTestSVG), opts)
+	if err != nil {
+		t.Fatalf("ParseFromData failed: %v", err)
+	}
+	defer tree.Close()
+
+	// Heights below, at and across the strip height, so that the last strip is partial
+	for _, height := range []uint32{1, 37, pngStripHeight, 150} {
+		transform := Scale(2, float32(height)/60)
+
+		var buf bytes.Buffer
+		if err := tree.EncodePNG(&buf, transform, 203, height); err != nil {
+			t.Fatalf("EncodePNG failed: %v", err)
+		}
+
+		want, err := tree.RenderNRGBA(transform, 203, height)
+		if err != nil {
+			t.Fatalf("RenderNRGBA failed: %v", err)
+		}
+		checkDecodedPNG(t, buf.Bytes(), want)
+	}
+
+	if err := tree.EncodePNG(&bytes.Buffer{}, IdentityTransform(), 0, 10); !errors.Is(err, ErrInvalidSize) {
+		t.Fatalf("Expected ErrInvalidSize, got %v", err)
+	}
+
+	// PNG dimensions are limited to 2^31-1, so nothing should be written for anything larger
+	for _, size := range [][2]uint32{{1 << 31, 1}, {1, 1 << 31}, {math.MaxUint32, math.MaxUint32}} {
+		var buf bytes.Buffer
+		if err := tree.EncodePNG(&buf, IdentityTransform(), size[0], size[1]); !errors.Is(err, ErrInvalidSize) {
+			t.Fatalf("%dx%d: expected ErrInvalidSize, got %v", size[0], size[1], err)
+		}
+		if buf.Len() != 0 {
+			t.Fatalf("%dx%d: expected nothing to be written, got %d bytes", size[0], size[1], buf.Len())
+		}
+	}
+
+	errWrite := errors.New("write failed")
+	if err := tree.EncodePNG(failingWriter{errWrite}, IdentityTransform(), 100, 60); !errors.Is(err, errWrite) {
+		t.Fatalf("Expected the write error, got %v", err)
+	}
+}
+
+func TestEncodePNGMaxPixels(t *testing.T) {
+	opts := NewOptions()
+	defer opts.Close()
+
+	// Enough pixels for a strip of the image, but not for all of it
+	opts.SetMaxPixels(203 * pngStripHeight)
+	tree, err := ParseFromData([]byte(tileTestSVG), opts)
+	if err != nil {
+		t.Fatalf("ParseFromData failed: %v", err)
+	}
+	defer tree.Close()
+
+	// The limit applies to the whole image, as it does for Render, so a huge image can't be encoded a
+	// strip at a time
+	var buf bytes.Buffer
+	if err := tree.EncodePNG(&buf, IdentityTransform(), 203, 150); !errors.Is(err, ErrTooManyPixels) {
+		t.Fatalf("Expected ErrTooManyPixels, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("Expected nothing to be written, got %d bytes", buf.Len())
+	}
+	if err := tree.EncodePNG(&bytes.Buffer{}, IdentityTransform(), 60000, 60000); !errors.Is(err, ErrTooManyPixels) {
+		t.Fatalf("Expected ErrTooManyPixels, got %v", err)
+	}
+
+	if err := tree.EncodePNG(&bytes.Buffer{}, IdentityTransform(), 203, pngStripHeight); err != nil {
+		t.Fatalf("EncodePNG within limit failed: %v", err)
+	}
+}
+
+func TestEncodePNGFilters(t *testing.T) {
+	opts := NewOptions()
+	defer opts.Close()
+
+	tree, err := ParseFromData([]byte(blurTestSVG), opts)
+	if err != nil {
+		t.Fatalf("ParseFromData failed: %v", err)
+	}
+	defer tree.Close()
+
+	const width, height = 200, 120
+	transform := Scale(2, 2)
+
+	var buf bytes.Buffer
+	if err := tree.EncodePNG(&buf, transform, width, height); err != nil {
+		t.Fatalf("EncodePNG failed: %v", err)
+	}
+	decoded, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("png.Decode failed: %v", err)
+	}
+	got, ok := decoded.(*image.NRGBA)
+	if !ok {
+		t.Fatalf("Expected *image.NRGBA, got %T", decoded)
+	}
+
+	want, err := tree.RenderNRGBA(transform, width, height)
+	if err != nil {
+		t.Fatalf("RenderNRGBA failed: %v", err)
+	}
+
+	// Filters are clipped to each strip, so only rows further from the strip boundary than the blurs and
+	// shadow reach match Render
+	const reach = 32
+	for y := 0; y < height; y++ {
+		// seam is the nearest row where one strip ends and the next starts
+		seam := (y + pngStripHeight/2) / pngStripHeight * pngStripHeight
+		if seam > 0 && seam < height && absInt(y-seam) < reach {
+			continue
+		}
+		for x := 0; x < width; x++ {
+			if got.NRGBAAt(x, y) != want.NRGBAAt(x, y) {
+				t.Fatalf("Pixel (%d,%d) incorrect: expected %v, got %v", x, y, want.NRGBAAt(x, y), got.NRGBAAt(x, y))
+			}
+		}
+	}
+}
+
+func TestPNGEncoder(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	// Noise, flat color and gradients each favor a different filter
+	img := image.NewNRGBA(image.Rect(0, 0, 67, 90))
+	for y := 0; y < 90; y++ {
+		for x := 0; x < 67; x++ {
+			i := img.PixOffset(x, y)
+			switch {
+			case y < 30:
+				rng.Read(img.Pix[i : i+4])
+			case y < 60:
+				copy(img.Pix[i:i+4], []byte{10, 200, 30, 255})
+			default:
+				copy(img.Pix[i:i+4], []byte{byte(x * 3), byte(y * 2), byte(x + y), byte(255 - x)})
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	enc, err := newPNGEncoder(&buf, 67, 90)
+	if err != nil {
+		t.Fatalf("newPNGEncoder failed: %v", err)
+	}
+	for y := 0; y < 90; y++ {
+		if err := enc.writeRow(img.Pix[y*img.Stride : (y+1)*img.Stride]); err != nil {
+			t.Fatalf("writeRow failed: %v", err)
+		}
+	}
+	if err := enc.close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	checkDecodedPNG(t, buf.Bytes(), img)
+}
+
+func checkDecodedPNG(t *testing.T, data []byte, want *image.NRGBA) {
+	t.Helper()
+
+	decoded, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("png.Decode failed: %v", err)
+	}
+	got, ok := decoded.(*image.NRGBA)
+	if !ok {
+		t.Fatalf("Expected *image.NRGBA, got %T", decoded)
+	}
+	if got.Bounds() != want.Bounds() {
+		t.Fatalf("Expected bounds %v, got %v", want.Bounds(), got.Bounds())
+	}
+	if !bytes.Equal(got.Pix, want.Pix) {
+		t.Fatal("Decoded pixels differ from the rendered image")
+	}
+}
+
+// failingWriter fails every write with err
+type failingWriter struct {
+	err error
+}
+
+func (w failingWriter) Write(p []byte) (int, error) {
+	return 0, w.err
+}
@@ -83,6 +83,7 @@ func renderWithDefaults(svgData []byte, outputFile string) {
 
 func renderWithHighDPI(svgData []byte, outputFile string) {
 	opts := resvg.NewOptions()
+	defer opts.Close()
 	opts.SetDPI(192.0) // Double the default DPI
 
 	tree, err := resvg.ParseFromData(svgData, opts)
@@ -90,14 +91,24 @@ func renderWithHighDPI(svgData []byte, outputFile string) {
 		fmt.Printf("Error parsing: %v\n", err)
 		return
 	}
+	defer tree.Close()
 
-	if tree.IsEmpty() {
+	if empty, err := tree.IsEmpty(); err != nil || empty {
 		fmt.Println("SVG is empty")
 		return
 	}
 
-	size := tree.GetImageSize()
-	img := tree.Render(resvg.IdentityTransform(), uint32(size.Width), uint32(size.Height))
+	size, err := tree.GetImageSize()
+	if err != nil {
+		fmt.Printf("Error getting size: %v\n", err)
+		return
+	}
+
+	img, err := tree.Render(resvg.IdentityTransform(), uint32(size.Width), uint32(size.Height))
+	if err != nil {
+		fmt.Printf("Error rendering: %v\n", err)
+		return
+	}
 
 	saveImage(img, outputFile)
 	fmt.Printf("Saved: %s (%dx%d)\n", outputFile, img.Bounds().Dx(), img.Bounds().Dy())
@@ -105,6 +116,7 @@ func renderWithHighDPI(svgData []byte, outputFile string) {
 
 func renderOptimizedForSpeed(svgData []byte, outputFile string) {
 	opts := resvg.NewOptions()
+	defer opts.Close()
 	opts.SetImageRenderingMode(resvg.ImageRenderingOptimizeSpeed)
 	opts.SetShapeRenderingMode(resvg.ShapeRenderingOptimizeSpeed)
 	opts.SetTextRenderingMode(resvg.TextRenderingOptimizeSpeed)
@@ -114,14 +126,24 @@ func renderOptimizedForSpeed(svgData []byte, outputFile string) {
 		fmt.Printf("Error parsing: %v\n", err)
 		return
 	}
+	defer tree.Close()
 
-	if tree.IsEmpty() {
+	if empty, err := tree.IsEmpty(); err != nil || empty {
 		fmt.Println("SVG is empty")
 		return
 	}
 
-	size := tree.GetImageSize()
-	img := tree.Render(resvg.IdentityTransform(), uint32(size.Width), uint32(size.Height))
+	size, err := tree.GetImageSize()
+	if err != nil {
+		fmt.Printf("Error getting size: %v\n", err)
+		return
+	}
+
+	img, err := tree.Render(resvg.IdentityTransform(), uint32(size.Width), uint32(size.Height))
+	if err != nil {
+		fmt.Printf("Error rendering: %v\n", err)
+		return
+	}
 
 	saveImage(img, outputFile)
 	fmt.Printf("Saved: %s (%dx%d)\n", outputFile, img.Bounds().Dx(), img.Bounds().Dy())
@@ -129,30 +151,37 @@ func renderOptimizedForSpeed(svgData []byte, outputFile string) {
 
 func renderAtScale(svgData []byte, outputFile string, scale float32) {
 	opts := resvg.NewOptions()
+	defer opts.Close()
 
 	tree, err := resvg.ParseFromData(svgData, opts)
 	if err != nil {
 		fmt.Printf("Error parsing: %v\n", err)
 		return
 	}
+	defer tree.Close()
 
-	if tree.IsEmpty() {
+	if empty, err := tree.IsEmpty(); err != nil || empty {
 		fmt.Println("SVG is empty")
 		return
 	}
 
-	size := tree.GetImageSize()
+	size, err := tree.GetImageSize()
+	if err != nil {
+		fmt.Printf("Error getting size: %v\n", err)
+		return
+	}
+
 	newWidth := uint32(float32(size.Width) * scale)
 	newHeight := uint32(float32(size.Height) * scale)
 
 	// Create a scaling transform
-	transform := resvg.Transform{
-		A: scale, B: 0,
-		C: 0, D: scale,
-		E: 0, F: 0,
-	}
+	transform := resvg.Scale(scale, scale)
 
-	img := tree.Render(transform, newWidth, newHeight)
+	img, err := tree.Render(transform, newWidth, newHeight)
+	if err != nil {
+		fmt.Printf("Error rendering: %v\n", err)
+		return
+	}
 
 	saveImage(img, outputFile)
 	fmt.Printf("Saved: %s (%dx%d at %.1fx scale)\n", outputFile, img.Bounds().Dx(), img.Bounds().Dy(), scale)
@@ -160,26 +189,33 @@ func renderAtScale(svgData []byte, outputFile string, scale float32) {
 
 func displaySVGInfo(svgData []byte) {
 	opts := resvg.NewOptions()
+	defer opts.Close()
+
 	tree, err := resvg.ParseFromData(svgData, opts)
 	if err != nil {
 		fmt.Printf("Error parsing: %v\n", err)
 		return
 	}
+	defer tree.Close()
 
-	if tree.IsEmpty() {
+	if empty, err := tree.IsEmpty(); err != nil || empty {
 		fmt.Println("  SVG is empty")
 		return
 	}
 
-	size := tree.GetImageSize()
+	size, err := tree.GetImageSize()
+	if err != nil {
+		fmt.Printf("Error getting size: %v\n", err)
+		return
+	}
 	fmt.Printf("  Natural size: %.1f x %.1f\n", size.Width, size.Height)
 
-	if objBBox, exists := tree.GetObjectBBox(); exists {
+	if objBBox, exists, _ := tree.GetObjectBBox(); exists {
 		fmt.Printf("  Object bbox: (%.1f, %.1f) %.1f x %.1f\n",
 			objBBox.X, objBBox.Y, objBBox.Width, objBBox.Height)
 	}
 
-	if imgBBox, exists := tree.GetImageBBox(); exists {
+	if imgBBox, exists, _ := tree.GetImageBBox(); exists {
 		fmt.Printf("  Image bbox: (%.1f, %.1f) %.1f x %.1f\n",
 			imgBBox.X, imgBBox.Y, imgBBox.Width, imgBBox.Height)
 	}
@@ -187,6 +223,7 @@ func displaySVGInfo(svgData []byte) {
 
 func renderWithSystemFonts(svgData []byte, outputFile string) {
 	opts := resvg.NewOptions()
+	defer opts.Close()
 
 	// Load system fonts (this can take a moment)
 	fmt.Println("  Loading system fonts...")
@@ -201,14 +238,24 @@ func renderWithSystemFonts(svgData []byte, outputFile string) {
 		fmt.Printf("Error parsing: %v\n", err)
 		return
 	}
+	defer tree.Close()
 
-	if tree.IsEmpty() {
+	if empty, err := tree.IsEmpty(); err != nil || empty {
 		fmt.Println("SVG is empty")
 		return
 	}
 
-	size := tree.GetImageSize()
-	img := tree.Render(resvg.IdentityTransform(), uint32(size.Width), uint32(size.Height))
+	size, err := tree.GetImageSize()
+	if err != nil {
+		fmt.Printf("Error getting size: %v\n", err)
+		return
+	}
+
+	img, err := tree.Render(resvg.IdentityTransform(), uint32(size.Width), uint32(size.Height))
+	if err != nil {
+		fmt.Printf("Error rendering: %v\n", err)
+		return
+	}
 
 	saveImage(img, outputFile)
 	fmt.Printf("Saved: %s (%dx%d with system fonts)\n", outputFile, img.Bounds().Dx(), img.Bounds().Dy())
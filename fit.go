@@ -0,0 +1,220 @@
+package resvg
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// FitMode controls how content is scaled to fit a target size
+type FitMode int
+
+const (
+	// FitContain scales the content uniformly so that all of it is visible, leaving empty space if the
+	// aspect ratios differ
+	FitContain FitMode = iota
+	// FitCover scales the content uniformly so that it covers the whole target, cropping it if the
+	// aspect ratios differ
+	FitCover
+	// FitFill stretches the content to exactly the target size, ignoring its aspect ratio
+	FitFill
+	// FitScaleDown behaves like FitContain, but never scales the content up
+	FitScaleDown
+	// FitNone does not scale the content
+	FitNone
+)
+
+// Align controls where content is placed in the target when it doesn't fill it exactly, like the
+// alignment values of the SVG preserveAspectRatio attribute. The zero value is AlignXMidYMid.
+type Align int
+
+const (
+	AlignXMidYMid Align = iota
+	AlignXMinYMin
+	AlignXMidYMin
+	AlignXMaxYMin
+	AlignXMinYMid
+	AlignXMaxYMid
+	AlignXMinYMax
+	AlignXMidYMax
+	AlignXMaxYMax
+)
+
+// FitOptions configures how content is fitted to a target size
+type FitOptions struct {
+	Mode  FitMode
+	Align Align
+
+	// Background is painted under the content, if set. Otherwise, the background is transparent.
+	Background color.Color
+}
+
+// factors returns the fraction of the leftover horizontal and vertical space that goes before the content
+func (a Align) factors() (x, y float64) {
+	switch a {
+	case AlignXMinYMin:
+		return 0, 0
+	case AlignXMidYMin:
+		return 0.5, 0
+	case AlignXMaxYMin:
+		return 1, 0
+	case AlignXMinYMid:
+		return 0, 0.5
+	case AlignXMaxYMid:
+		return 1, 0.5
+	case AlignXMinYMax:
+		return 0, 1
+	case AlignXMidYMax:
+		return 0.5, 1
+	case AlignXMaxYMax:
+		return 1, 1
+	default:
+		return 0.5, 0.5
+	}
+}
+
+// fitTransform returns the transform that maps the content rectangle src onto a width x height target
+func fitTransform(src Rect, width, height uint32, mode FitMode, align Align) Transform {
+	srcW := float64(src.Width)
+	srcH := float64(src.Height)
+	targetW := float64(width)
+	targetH := float64(height)
+
+	scaleX := targetW / srcW
+	scaleY := targetH / srcH
+	switch mode {
+	case FitCover:
+		scaleX = math.Max(scaleX, scaleY)
+		scaleY = scaleX
+	case FitFill:
+		// Scale each axis independently
+	case FitScaleDown:
+		scaleX = math.Min(1, math.Min(scaleX, scaleY))
+		scaleY = scaleX
+	case FitNone:
+		scaleX = 1
+		scaleY = 1
+	default:
+		scaleX = math.Min(scaleX, scaleY)
+		scaleY = scaleX
+	}
+
+	// Distribute the leftover space (negative when cropping) according to the alignment
+	alignX, alignY := align.factors()
+	tx := (targetW-srcW*scaleX)*alignX - float64(src.X)*scaleX
+	ty := (targetH-srcH*scaleY)*alignY - float64(src.Y)*scaleY
+
+	return Transform{
+		A: float32(scaleX),
+		D: float32(scaleY),
+		E: float32(tx),
+		F: float32(ty),
+	}
+}
+
+// RenderFit renders the SVG tree to a width x height RGBA image, scaling and aligning the content
+// according to fit
+func (t *RenderTree) RenderFit(width, height uint32, fit FitOptions) (*image.RGBA, error) {
+	size, err := t.naturalSize()
+	if err != nil {
+		return nil, err
+	}
+
+	return t.RenderRegion(Rect{Width: size.Width, Height: size.Height}, width, height, fit)
+}
+
+// RenderScaled renders the SVG tree to an RGBA image at its natural size multiplied by scale,
+// for example 2 for a 2x variant. The image dimensions are rounded up to whole pixels.
+func (t *RenderTree) RenderScaled(scale float64) (*image.RGBA, error) {
+	size, err := t.naturalSize()
+	if err != nil {
+		return nil, err
+	}
+
+	width, err := scaleDimension(size.Width, scale)
+	if err != nil {
+		return nil, err
+	}
+	height, err := scaleDimension(size.Height, scale)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.Render(Scale(float32(scale), float32(scale)), width, height)
+}
+
+// RenderAtWidth renders the SVG tree to an RGBA image with the given width, scaling the height to
+// preserve the aspect ratio. The height is rounded up to whole pixels.
+func (t *RenderTree) RenderAtWidth(width uint32) (*image.RGBA, error) {
+	size, err := t.naturalSize()
+	if err != nil {
+		return nil, err
+	}
+
+	scale := float64(width) / float64(size.Width)
+	height, err := scaleDimension(size.Height, scale)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.Render(Scale(float32(scale), float32(scale)), width, height)
+}
+
+// RenderAtHeight renders the SVG tree to an RGBA image with the given height, scaling the width to
+// preserve the aspect ratio. The width is rounded up to whole pixels.
+func (t *RenderTree) RenderAtHeight(height uint32) (*image.RGBA, error) {
+	size, err := t.naturalSize()
+	if err != nil {
+		return nil, err
+	}
+
+	scale := float64(height) / float64(size.Height)
+	width, err := scaleDimension(size.Width, scale)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.Render(Scale(float32(scale), float32(scale)), width, height)
+}
+
+// naturalSize returns the natural size of the SVG, failing if it can't be used for scaling
+func (t *RenderTree) naturalSize() (Size, error) {
+	size, err := t.GetImageSize()
+	if err != nil {
+		return Size{}, err
+	}
+	if size.Width <= 0 || size.Height <= 0 {
+		return Size{}, &Error{Op: "render", Err: ErrInvalidDimensions}
+	}
+	return size, nil
+}
+
+// scaleDimension scales a natural dimension to whole pixels, rounding up
+func scaleDimension(v float32, scale float64) (uint32, error) {
+	scaled := math.Ceil(float64(v) * scale)
+	if !(scale > 0) || scaled > math.MaxUint32 {
+		return 0, &Error{Op: "render", Err: fmt.Errorf("%w: scale %g", ErrInvalidSize, scale)}
+	}
+	return uint32(scaled), nil
+}
+
+// fillBackground composites the premultiplied pixels of img over an opaque or translucent background color
+func fillBackground(img *image.RGBA, background color.Color) {
+	bg := color.RGBAModel.Convert(background).(color.RGBA)
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			i := img.PixOffset(x, y)
+			remaining := 255 - uint32(img.Pix[i+3])
+			if remaining == 0 {
+				continue
+			}
+
+			img.Pix[i+0] += uint8((uint32(bg.R)*remaining + 127) / 255)
+			img.Pix[i+1] += uint8((uint32(bg.G)*remaining + 127) / 255)
+			img.Pix[i+2] += uint8((uint32(bg.B)*remaining + 127) / 255)
+			img.Pix[i+3] += uint8((uint32(bg.A)*remaining + 127) / 255)
+		}
+	}
+}
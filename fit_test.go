@@ -0,0 +1,184 @@
+package resvg
+
+import (
+	"errors"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestFitTransform(t *testing.T) {
+	// A 100x50 source fitted into a 200x200 target
+	src := Rect{Width: 100, Height: 50}
+
+	tests := []struct {
+		name     string
+		mode     FitMode
+		align    Align
+		expected Transform
+	}{
+		{"contain", FitContain, AlignXMidYMid, Transform{A: 2, D: 2, E: 0, F: 50}},
+		{"contain top", FitContain, AlignXMidYMin, Transform{A: 2, D: 2, E: 0, F: 0}},
+		{"contain bottom", FitContain, AlignXMaxYMax, Transform{A: 2, D: 2, E: 0, F: 100}},
+		{"cover", FitCover, AlignXMidYMid, Transform{A: 4, D: 4, E: -100, F: 0}},
+		{"cover left", FitCover, AlignXMinYMin, Transform{A: 4, D: 4, E: 0, F: 0}},
+		{"cover right", FitCover, AlignXMaxYMid, Transform{A: 4, D: 4, E: -200, F: 0}},
+		{"fill", FitFill, AlignXMidYMid, Transform{A: 2, D: 4, E: 0, F: 0}},
+		{"scale down", FitScaleDown, AlignXMidYMid, Transform{A: 1, D: 1, E: 50, F: 75}},
+		{"none", FitNone, AlignXMinYMax, Transform{A: 1, D: 1, E: 0, F: 150}},
+	}
+
+	for _, test := range tests {
+		got := fitTransform(src, 200, 200, test.mode, test.align)
+		if got != test.expected {
+			t.Fatalf("%s: expected %+v, got %+v", test.name, test.expected, got)
+		}
+	}
+
+	// Scale down should shrink content that is larger than the target
+	got := fitTransform(Rect{Width: 400, Height: 400}, 200, 100, FitScaleDown, AlignXMinYMin)
+	if expected := (Transform{A: 0.25, D: 0.25}); got != expected {
+		t.Fatalf("scale down larger: expected %+v, got %+v", expected, got)
+	}
+
+	// The source offset should be taken into account
+	got = fitTransform(Rect{X: 10, Y: 20, Width: 100, Height: 100}, 200, 200, FitContain, AlignXMidYMid)
+	if expected := (Transform{A: 2, D: 2, E: -20, F: -40}); got != expected {
+		t.Fatalf("offset source: expected %+v, got %+v", expected, got)
+	}
+}
+
+func TestRenderFit(t *testing.T) {
+	// A tall SVG with a red top half and a blue bottom half
+	svgData := []byte(`<svg width="10" height="20" xmlns="http://www.w3.org/2000/svg">
+		<rect width="10" height="10" fill="red"/>
+		<rect y="10" width="10" height="10" fill="blue"/>
+	</svg>`)
+
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+	white := color.RGBA{255, 255, 255, 255}
+
+	// Cover aligned to the top should only show the red half
+	img, err := RenderFit(svgData, 10, 10, FitOptions{Mode: FitCover, Align: AlignXMidYMin})
+	if err != nil {
+		t.Fatalf("RenderFit failed: %v", err)
+	}
+	for _, y := range []int{0, 9} {
+		if got := img.RGBAAt(5, y); got != red {
+			t.Fatalf("Cover pixel (5,%d) incorrect: expected %v, got %v", y, red, got)
+		}
+	}
+
+	// Cover aligned to the bottom should only show the blue half
+	img, err = RenderFit(svgData, 10, 10, FitOptions{Mode: FitCover, Align: AlignXMidYMax})
+	if err != nil {
+		t.Fatalf("RenderFit failed: %v", err)
+	}
+	if got := img.RGBAAt(5, 0); got != blue {
+		t.Fatalf("Cover pixel (5,0) incorrect: expected %v, got %v", blue, got)
+	}
+
+	// Contain with a background should paint the letterbox
+	img, err = RenderFit(svgData, 20, 20, FitOptions{Mode: FitContain, Background: color.White})
+	if err != nil {
+		t.Fatalf("RenderFit failed: %v", err)
+	}
+	if got := img.RGBAAt(2, 10); got != white {
+		t.Fatalf("Background pixel incorrect: expected %v, got %v", white, got)
+	}
+	if got := img.RGBAAt(10, 5); got != red {
+		t.Fatalf("Content pixel incorrect: expected %v, got %v", red, got)
+	}
+
+	// Without a background the letterbox should stay transparent
+	img, err = RenderScaledToSize(svgData, 20, 20)
+	if err != nil {
+		t.Fatalf("RenderScaledToSize failed: %v", err)
+	}
+	if got := img.RGBAAt(2, 10); got != (color.RGBA{}) {
+		t.Fatalf("Letterbox pixel should be transparent, got %v", got)
+	}
+}
+
+func TestFillBackground(t *testing.T) {
+	svgData := []byte(`<svg width="2" height="2" xmlns="http://www.w3.org/2000/svg">
+		<rect width="2" height="2" fill="red" fill-opacity="0.5"/>
+	</svg>`)
+
+	img, err := RenderFit(svgData, 2, 2, FitOptions{Mode: FitFill, Background: color.White})
+	if err != nil {
+		t.Fatalf("RenderFit failed: %v", err)
+	}
+
+	if got, expected := img.RGBAAt(0, 0), (color.RGBA{255, 127, 127, 255}); got != expected {
+		t.Fatalf("Composited pixel incorrect: expected %v, got %v", expected, got)
+	}
+}
+
+func TestRenderTreeScaling(t *testing.T) {
+	svgData := []byte(`<svg width="10" height="20" xmlns="http://www.w3.org/2000/svg">
+		<rect width="10" height="20" fill="red"/>
+	</svg>`)
+
+	opts := NewOptions()
+	tree, err := ParseFromData(svgData, opts)
+	if err != nil {
+		t.Fatalf("ParseFromData failed: %v", err)
+	}
+	defer tree.Close()
+
+	red := color.RGBA{255, 0, 0, 255}
+
+	// Emit several variants from the same parsed tree
+	for _, scale := range []float64{1, 2, 3, 0.5} {
+		img, err := tree.RenderScaled(scale)
+		if err != nil {
+			t.Fatalf("RenderScaled(%g) failed: %v", scale, err)
+		}
+
+		width, height := int(10*scale), int(20*scale)
+		if bounds := img.Bounds(); bounds.Dx() != width || bounds.Dy() != height {
+			t.Fatalf("RenderScaled(%g): expected %dx%d image, got %dx%d", scale, width, height, bounds.Dx(), bounds.Dy())
+		}
+		if got := img.RGBAAt(width-1, height-1); got != red {
+			t.Fatalf("RenderScaled(%g): bottom right pixel incorrect: %v", scale, got)
+		}
+	}
+
+	img, err := tree.RenderAtWidth(25)
+	if err != nil {
+		t.Fatalf("RenderAtWidth failed: %v", err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != 25 || bounds.Dy() != 50 {
+		t.Fatalf("RenderAtWidth: expected 25x50 image, got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+
+	img, err = tree.RenderAtHeight(10)
+	if err != nil {
+		t.Fatalf("RenderAtHeight failed: %v", err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != 5 || bounds.Dy() != 10 {
+		t.Fatalf("RenderAtHeight: expected 5x10 image, got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+
+	img, err = tree.RenderFit(40, 40, FitOptions{Mode: FitContain, Align: AlignXMinYMin})
+	if err != nil {
+		t.Fatalf("RenderFit failed: %v", err)
+	}
+	if got := img.RGBAAt(19, 39); got != red {
+		t.Fatalf("RenderFit: content pixel incorrect: %v", got)
+	}
+	if got := img.RGBAAt(21, 0); got != (color.RGBA{}) {
+		t.Fatalf("RenderFit: letterbox pixel should be transparent, got %v", got)
+	}
+
+	for _, scale := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		if _, err := tree.RenderScaled(scale); !errors.Is(err, ErrInvalidSize) {
+			t.Fatalf("RenderScaled(%g): expected ErrInvalidSize, got %v", scale, err)
+		}
+	}
+	if _, err := tree.RenderAtWidth(0); !errors.Is(err, ErrInvalidSize) {
+		t.Fatalf("RenderAtWidth(0): expected ErrInvalidSize, got %v", err)
+	}
+}
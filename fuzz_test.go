@@ -0,0 +1,133 @@
+package resvg
+
+import (
+	"os"
+	"testing"
+)
+
+// fuzzSeeds are edge cases for the fuzz targets, alongside examples/test.svg. Inputs that crash or hang
+// the library are saved by the fuzzer under testdata/fuzz, and run as regression tests by go test.
+var fuzzSeeds = []string{
+	"",
+	"not svg",
+	"\x1f\x8b",
+	"\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\x03",
+	"\xff\xfe<\x00s\x00v\x00g\x00",
+	`<svg xmlns="http://www.w3.org/2000/svg"/>`,
+	`<svg xmlns="http://www.w3.org/2000/svg" width="0" height="0"/>`,
+	`<svg xmlns="http://www.w3.org/2000/svg" width="-10" height="10"><rect width="10" height="10"/></svg>`,
+	`<svg xmlns="http://www.w3.org/2000/svg" width="1e38" height="1e38"><rect width="10" height="10"/></svg>`,
+	`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 0.000001 0.000001"><rect width="10" height="10"/></svg>`,
+	`<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"><rect transform="scale(NaN)" width="10" height="10"/></svg>`,
+	`<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"><rect transform="matrix(0 0 0 0 0 0)" width="10" height="10"/></svg>`,
+	`<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"><rect width="10" height="10"`,
+	`<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"><use id="u" href="#u"/></svg>`,
+	`<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10">
+		<pattern id="p" width="1" height="1"><rect width="10" height="10" fill="url(#p)"/></pattern>
+		<rect width="10" height="10" fill="url(#p)"/>
+	</svg>`,
+	`<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10">
+		<filter id="f"><feGaussianBlur stdDeviation="1e9"/></filter>
+		<rect width="10" height="10" filter="url(#f)"/>
+	</svg>`,
+	`<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10">
+		<filter id="f" x="-1e9" y="-1e9" width="1e10" height="1e10"><feFlood flood-color="red"/></filter>
+		<rect width="10" height="10" filter="url(#f)"/>
+	</svg>`,
+	`<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"><path d="M 0 0 L 1e38 1e38 L -1e38 1e38 Z"/></svg>`,
+	`<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"><text font-size="1e9">resvg</text></svg>`,
+	`<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"><image width="10" height="10" href="data:image/png;base64,AAAA"/></svg>`,
+	`<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"><image width="10" height="10" href="data:image/svg+xml;base64,PHN2Zz4="/></svg>`,
+	billionLaughs,
+	nestedSVG(1000),
+}
+
+// fuzzOptions returns options that keep the fuzz targets within reasonable time and memory
+func fuzzOptions(t *testing.T) *Options {
+	opts := NewOptions()
+	t.Cleanup(func() { opts.Close() })
+
+	if err := opts.SetMaxPixels(1 << 22); err != nil {
+		t.Fatalf("SetMaxPixels failed: %v", err)
+	}
+	if err := opts.SetLimits(Limits{
+		MaxBytes:             1 << 20,
+		MaxDecompressedBytes: 4 << 20,
+		MaxDepth:             256,
+		MaxElements:          10000,
+		MaxEntityExpansion:   1 << 16,
+	}); err != nil {
+		t.Fatalf("SetLimits failed: %v", err)
+	}
+	return opts
+}
+
+func addFuzzSeeds(f *testing.F, add func(data []byte)) {
+	testSVG, err := os.ReadFile("examples/test.svg")
+	if err != nil {
+		f.Fatalf("ReadFile failed: %v", err)
+	}
+	add(testSVG)
+	for _, seed := range fuzzSeeds {
+		add([]byte(seed))
+	}
+}
+
+func FuzzParseFromData(f *testing.F) {
+	addFuzzSeeds(f, func(data []byte) { f.Add(data) })
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		opts := fuzzOptions(t)
+
+		tree, err := ParseFromData(data, opts)
+		if err != nil {
+			if tree != nil {
+				t.Fatalf("ParseFromData returned a tree with error %v", err)
+			}
+			return
+		}
+		defer tree.Close()
+
+		if _, err := tree.IsEmpty(); err != nil {
+			t.Fatalf("IsEmpty failed: %v", err)
+		}
+		if _, err := tree.GetImageSize(); err != nil {
+			t.Fatalf("GetImageSize failed: %v", err)
+		}
+		if _, _, err := tree.GetImageBBox(); err != nil {
+			t.Fatalf("GetImageBBox failed: %v", err)
+		}
+		if _, _, err := tree.GetObjectBBox(); err != nil {
+			t.Fatalf("GetObjectBBox failed: %v", err)
+		}
+
+		img, err := tree.RenderFit(64, 64, FitOptions{})
+		if err == nil && (img.Bounds().Dx() != 64 || img.Bounds().Dy() != 64) {
+			t.Fatalf("RenderFit returned a %v image", img.Bounds())
+		}
+	})
+}
+
+func FuzzRenderScaledToSize(f *testing.F) {
+	addFuzzSeeds(f, func(data []byte) {
+		f.Add(data, uint16(100), uint16(100))
+		f.Add(data, uint16(1), uint16(4096))
+	})
+	f.Add([]byte(parseTestSVG), uint16(0), uint16(0))
+	f.Add([]byte(parseTestSVG), uint16(65535), uint16(65535))
+
+	f.Fuzz(func(t *testing.T, data []byte, width, height uint16) {
+		renderer := NewRenderer(fuzzOptions(t))
+
+		img, err := renderer.RenderScaledToSize(data, uint32(width), uint32(height))
+		if err != nil {
+			if img != nil {
+				t.Fatalf("RenderScaledToSize returned an image with error %v", err)
+			}
+			return
+		}
+		if img.Bounds().Dx() != int(width) || img.Bounds().Dy() != int(height) {
+			t.Fatalf("Expected %dx%d image, got %v", width, height, img.Bounds())
+		}
+	})
+}
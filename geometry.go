@@ -0,0 +1,165 @@
+package resvg
+
+import (
+	"image"
+	"math"
+)
+
+// Rounding controls how ToImageRect converts fractional coordinates to whole pixels
+type Rounding int
+
+const (
+	// RoundOut rounds to the smallest pixel rectangle that covers the whole of the rectangle
+	RoundOut Rounding = iota
+
+	// RoundIn rounds to the largest pixel rectangle that lies entirely inside the rectangle
+	RoundIn
+
+	// RoundNearest rounds each edge to the nearest pixel boundary
+	RoundNearest
+)
+
+// Empty reports whether r has no area
+func (r Rect) Empty() bool {
+	return !(r.Width > 0 && r.Height > 0)
+}
+
+// Center returns the point in the middle of r
+func (r Rect) Center() (x, y float32) {
+	return r.X + r.Width/2, r.Y + r.Height/2
+}
+
+// Contains reports whether the point (x, y) lies within r. Points on the left and top edges are inside r,
+// and points on the right and bottom edges are not.
+func (r Rect) Contains(x, y float32) bool {
+	return x >= r.X && x < r.X+r.Width && y >= r.Y && y < r.Y+r.Height
+}
+
+// Union returns the smallest rectangle that contains both r and other. Empty rectangles are ignored.
+func (r Rect) Union(other Rect) Rect {
+	if r.Empty() {
+		return other
+	}
+	if other.Empty() {
+		return r
+	}
+
+	minX := minFloat32(r.X, other.X)
+	minY := minFloat32(r.Y, other.Y)
+	maxX := maxFloat32(r.X+r.Width, other.X+other.Width)
+	maxY := maxFloat32(r.Y+r.Height, other.Y+other.Height)
+	return Rect{X: minX, Y: minY, Width: maxX - minX, Height: maxY - minY}
+}
+
+// Intersect returns the largest rectangle contained by both r and other. If they don't overlap, the zero
+// Rect is returned.
+func (r Rect) Intersect(other Rect) Rect {
+	minX := maxFloat32(r.X, other.X)
+	minY := maxFloat32(r.Y, other.Y)
+	maxX := minFloat32(r.X+r.Width, other.X+other.Width)
+	maxY := minFloat32(r.Y+r.Height, other.Y+other.Height)
+
+	intersection := Rect{X: minX, Y: minY, Width: maxX - minX, Height: maxY - minY}
+	if intersection.Empty() {
+		return Rect{}
+	}
+	return intersection
+}
+
+// Inset returns r shrunk by d on every side. If r is too small for that, an empty rectangle at the
+// center of r is returned in the dimension that's too small. A negative d grows r instead.
+func (r Rect) Inset(d float32) Rect {
+	if r.Width < 2*d {
+		r.X += r.Width / 2
+		r.Width = 0
+	} else {
+		r.X += d
+		r.Width -= 2 * d
+	}
+	if r.Height < 2*d {
+		r.Y += r.Height / 2
+		r.Height = 0
+	} else {
+		r.Y += d
+		r.Height -= 2 * d
+	}
+	return r
+}
+
+// Outset returns r grown by d on every side, such as to add padding around a bounding box
+func (r Rect) Outset(d float32) Rect {
+	return r.Inset(-d)
+}
+
+// Scale returns r with its position and size multiplied by s, such as to convert a bounding box in
+// document units to pixels in an image rendered at scale s
+func (r Rect) Scale(s float32) Rect {
+	return Rect{X: r.X * s, Y: r.Y * s, Width: r.Width * s, Height: r.Height * s}
+}
+
+// ToImageRect converts r to an image.Rectangle of whole pixels, rounding its edges as specified by rounding.
+// If no pixels are left, such as when rounding in a rectangle less than a pixel wide, an empty rectangle is
+// returned.
+func (r Rect) ToImageRect(rounding Rounding) image.Rectangle {
+	minX, minY := float64(r.X), float64(r.Y)
+	maxX, maxY := float64(r.X+r.Width), float64(r.Y+r.Height)
+
+	var rect image.Rectangle
+	switch rounding {
+	case RoundIn:
+		rect.Min = image.Pt(int(math.Ceil(minX)), int(math.Ceil(minY)))
+		rect.Max = image.Pt(int(math.Floor(maxX)), int(math.Floor(maxY)))
+	case RoundNearest:
+		rect.Min = image.Pt(int(math.Round(minX)), int(math.Round(minY)))
+		rect.Max = image.Pt(int(math.Round(maxX)), int(math.Round(maxY)))
+	default:
+		rect.Min = image.Pt(int(math.Floor(minX)), int(math.Floor(minY)))
+		rect.Max = image.Pt(int(math.Ceil(maxX)), int(math.Ceil(maxY)))
+	}
+	if rect.Empty() {
+		return image.Rectangle{Min: rect.Min, Max: rect.Min}
+	}
+	return rect
+}
+
+// Fit returns the largest scale at which s fits entirely within target, preserving the aspect ratio.
+// If either size has no area, it returns 0.
+func (s Size) Fit(target Size) float64 {
+	scaleX, scaleY, ok := s.scales(target)
+	if !ok {
+		return 0
+	}
+	return math.Min(scaleX, scaleY)
+}
+
+// Fill returns the smallest scale at which s covers the whole of target, preserving the aspect ratio.
+// If either size has no area, it returns 0.
+func (s Size) Fill(target Size) float64 {
+	scaleX, scaleY, ok := s.scales(target)
+	if !ok {
+		return 0
+	}
+	return math.Max(scaleX, scaleY)
+}
+
+// scales returns the horizontal and vertical scales that would stretch s to target
+func (s Size) scales(target Size) (scaleX, scaleY float64, ok bool) {
+	if !(s.Width > 0 && s.Height > 0 && target.Width > 0 && target.Height > 0) {
+		return 0, 0, false
+	}
+	return float64(target.Width) / float64(s.Width), float64(target.Height) / float64(s.Height), true
+}
+
+func minFloat32(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxFloat32(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
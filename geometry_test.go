@@ -0,0 +1,163 @@
+package resvg
+
+import (
+	"image"
+	"testing"
+)
+
+func TestRectEmptyAndCenter(t *testing.T) {
+	tests := []struct {
+		rect   Rect
+		empty  bool
+		center [2]float32
+	}{
+		{Rect{X: 0, Y: 0, Width: 10, Height: 20}, false, [2]float32{5, 10}},
+		{Rect{X: -4, Y: 2, Width: 2, Height: 2}, false, [2]float32{-3, 3}},
+		{Rect{X: 5, Y: 5, Width: 0, Height: 10}, true, [2]float32{5, 10}},
+		{Rect{X: 5, Y: 5, Width: 10, Height: -1}, true, [2]float32{10, 4.5}},
+		{Rect{}, true, [2]float32{0, 0}},
+	}
+
+	for _, test := range tests {
+		if got := test.rect.Empty(); got != test.empty {
+			t.Errorf("%+v: Empty returned %v, expected %v", test.rect, got, test.empty)
+		}
+		if x, y := test.rect.Center(); x != test.center[0] || y != test.center[1] {
+			t.Errorf("%+v: Center returned (%v, %v), expected %v", test.rect, x, y, test.center)
+		}
+	}
+}
+
+func TestRectContains(t *testing.T) {
+	rect := Rect{X: 10, Y: 20, Width: 30, Height: 40}
+
+	tests := []struct {
+		x, y float32
+		want bool
+	}{
+		{10, 20, true},
+		{25, 40, true},
+		{39.9, 59.9, true},
+		{40, 40, false},
+		{25, 60, false},
+		{9.9, 40, false},
+		{25, 19.9, false},
+	}
+
+	for _, test := range tests {
+		if got := rect.Contains(test.x, test.y); got != test.want {
+			t.Errorf("Contains(%v, %v) returned %v, expected %v", test.x, test.y, got, test.want)
+		}
+	}
+}
+
+func TestRectUnionIntersect(t *testing.T) {
+	a := Rect{X: 0, Y: 0, Width: 10, Height: 10}
+
+	tests := []struct {
+		name      string
+		b         Rect
+		union     Rect
+		intersect Rect
+	}{
+		{"overlapping", Rect{X: 5, Y: 5, Width: 10, Height: 10},
+			Rect{X: 0, Y: 0, Width: 15, Height: 15}, Rect{X: 5, Y: 5, Width: 5, Height: 5}},
+		{"contained", Rect{X: 2, Y: 3, Width: 4, Height: 5},
+			a, Rect{X: 2, Y: 3, Width: 4, Height: 5}},
+		{"disjoint", Rect{X: 20, Y: -10, Width: 5, Height: 5},
+			Rect{X: 0, Y: -10, Width: 25, Height: 20}, Rect{}},
+		{"touching", Rect{X: 10, Y: 0, Width: 5, Height: 10},
+			Rect{X: 0, Y: 0, Width: 15, Height: 10}, Rect{}},
+		{"empty", Rect{X: 100, Y: 100},
+			a, Rect{}},
+	}
+
+	for _, test := range tests {
+		if got := a.Union(test.b); got != test.union {
+			t.Errorf("%s: Union returned %+v, expected %+v", test.name, got, test.union)
+		}
+		if got := test.b.Union(a); got != test.union {
+			t.Errorf("%s: reversed Union returned %+v, expected %+v", test.name, got, test.union)
+		}
+		if got := a.Intersect(test.b); got != test.intersect {
+			t.Errorf("%s: Intersect returned %+v, expected %+v", test.name, got, test.intersect)
+		}
+		if got := test.b.Intersect(a); got != test.intersect {
+			t.Errorf("%s: reversed Intersect returned %+v, expected %+v", test.name, got, test.intersect)
+		}
+	}
+}
+
+func TestRectInsetOutsetScale(t *testing.T) {
+	rect := Rect{X: 10, Y: 20, Width: 30, Height: 8}
+
+	tests := []struct {
+		name string
+		got  Rect
+		want Rect
+	}{
+		{"inset", rect.Inset(2), Rect{X: 12, Y: 22, Width: 26, Height: 4}},
+		{"inset too far", rect.Inset(5), Rect{X: 15, Y: 24, Width: 20, Height: 0}},
+		{"outset", rect.Outset(5), Rect{X: 5, Y: 15, Width: 40, Height: 18}},
+		{"negative inset", rect.Inset(-5), rect.Outset(5)},
+		{"scale", rect.Scale(2), Rect{X: 20, Y: 40, Width: 60, Height: 16}},
+		{"scale down", rect.Scale(0.5), Rect{X: 5, Y: 10, Width: 15, Height: 4}},
+	}
+
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s: got %+v, expected %+v", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestRectToImageRect(t *testing.T) {
+	tests := []struct {
+		name     string
+		rect     Rect
+		rounding Rounding
+		want     image.Rectangle
+	}{
+		{"whole pixels", Rect{X: 1, Y: 2, Width: 3, Height: 4}, RoundOut, image.Rect(1, 2, 4, 6)},
+		{"out", Rect{X: 1.2, Y: 2.7, Width: 3.1, Height: 4.1}, RoundOut, image.Rect(1, 2, 5, 7)},
+		{"in", Rect{X: 1.2, Y: 2.7, Width: 3.1, Height: 4.1}, RoundIn, image.Rect(2, 3, 4, 6)},
+		{"nearest", Rect{X: 1.2, Y: 2.7, Width: 3.1, Height: 4.1}, RoundNearest, image.Rect(1, 3, 4, 7)},
+		{"negative out", Rect{X: -1.5, Y: -0.5, Width: 1, Height: 1}, RoundOut, image.Rect(-2, -1, 0, 1)},
+		{"negative in", Rect{X: -1.5, Y: -0.5, Width: 2, Height: 2}, RoundIn, image.Rect(-1, 0, 0, 1)},
+		{"in leaves nothing", Rect{X: 1.2, Y: 1.2, Width: 0.5, Height: 5}, RoundIn, image.Rectangle{}},
+		{"zero value is out", Rect{X: 0.5, Y: 0.5, Width: 1, Height: 1}, Rounding(0), image.Rect(0, 0, 2, 2)},
+	}
+
+	for _, test := range tests {
+		got := test.rect.ToImageRect(test.rounding)
+		if got != test.want && !(got.Empty() && test.want.Empty()) {
+			t.Errorf("%s: got %v, expected %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSizeFitFill(t *testing.T) {
+	tests := []struct {
+		name   string
+		size   Size
+		target Size
+		fit    float64
+		fill   float64
+	}{
+		{"same", Size{100, 50}, Size{100, 50}, 1, 1},
+		{"wider target", Size{100, 50}, Size{400, 100}, 2, 4},
+		{"taller target", Size{100, 50}, Size{100, 200}, 1, 4},
+		{"smaller target", Size{200, 100}, Size{50, 50}, 0.25, 0.5},
+		{"empty size", Size{0, 50}, Size{100, 100}, 0, 0},
+		{"empty target", Size{100, 50}, Size{100, 0}, 0, 0},
+	}
+
+	for _, test := range tests {
+		if got := test.size.Fit(test.target); got != test.fit {
+			t.Errorf("%s: Fit returned %v, expected %v", test.name, got, test.fit)
+		}
+		if got := test.size.Fill(test.target); got != test.fill {
+			t.Errorf("%s: Fill returned %v, expected %v", test.name, got, test.fill)
+		}
+	}
+}
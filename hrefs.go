@@ -0,0 +1,470 @@
+package resvg
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// xlinkNamespace is the namespace of XLink attributes such as xlink:href
+const xlinkNamespace = "http://www.w3.org/1999/xlink"
+
+// maxHrefExpansion is the most bytes of entity replacement text rewriteHrefs will expand while looking for hrefs
+const maxHrefExpansion = 16 << 20
+
+// predefinedEntities are the entities every XML document can refer to without declaring them
+var predefinedEntities = map[string]string{
+	"lt":   "<",
+	"gt":   ">",
+	"amp":  "&",
+	"apos": "'",
+	"quot": `"`,
+}
+
+// rewriteHrefs replaces each external href on the image elements in data with the result of calling rewrite
+// with it. It stops at the first error rewrite returns.
+//
+// data is read the way an XML parser reads it, so that an href can't be hidden: attribute values may contain
+// '>', the XLink namespace may be bound to any prefix, and references to entities declared in the DTD are
+// expanded. An entity reference whose replacement text holds an href that's rewritten is replaced by the
+// rewritten replacement text.
+func rewriteHrefs(data []byte, rewrite func(href string) (string, error)) ([]byte, error) {
+	w := hrefRewriter{match: isExternalHref, rewrite: rewrite, entities: entityDecls{}}
+	out, _, err := w.walk(data, nil)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// dataHrefs returns the data: URI hrefs on the image elements in data, found the same way as by rewriteHrefs
+func dataHrefs(data []byte) ([]string, error) {
+	var hrefs []string
+	w := hrefRewriter{
+		match: isDataHref,
+		rewrite: func(href string) (string, error) {
+			hrefs = append(hrefs, href)
+			return href, nil
+		},
+		entities: entityDecls{},
+	}
+	if _, _, err := w.walk(data, nil); err != nil {
+		return nil, err
+	}
+	return hrefs, nil
+}
+
+// hrefRewriter holds the state of rewriteHrefs as it walks a document
+type hrefRewriter struct {
+	match    func(href string) bool
+	rewrite  func(href string) (string, error)
+	entities entityDecls
+
+	// markup caches whether the replacement text of each entity may contain elements
+	markup map[string]bool
+
+	// expanded is the number of bytes of replacement text expanded so far
+	expanded int64
+}
+
+// nsBinding binds a namespace prefix to a namespace URI
+type nsBinding struct {
+	prefix, uri string
+}
+
+// tagAttr is an attribute of a start tag, with the offsets of its value within the tag, excluding the quotes
+type tagAttr struct {
+	name       string
+	start, end int
+}
+
+// walk rewrites the hrefs in the markup in data, with the namespace bindings in ns in scope. If nothing is
+// rewritten, it returns data itself and false.
+func (w *hrefRewriter) walk(data []byte, ns []nsBinding) ([]byte, bool, error) {
+	var (
+		out     []byte
+		last    int
+		changed bool
+	)
+	replace := func(start, end int, value []byte) {
+		out = append(out, data[last:start]...)
+		out = append(out, value...)
+		last = end
+		changed = true
+	}
+
+	// scopes holds the number of namespace bindings in scope at the start of each open element
+	var scopes []int
+
+	i := 0
+	for i < len(data) {
+		end := len(data)
+		if lt := bytes.IndexByte(data[i:], '<'); lt >= 0 {
+			end = i + lt
+		}
+		if err := w.walkText(data, i, end, ns, replace); err != nil {
+			return nil, false, err
+		}
+		i = end
+		if i == len(data) {
+			break
+		}
+
+		rest := data[i:]
+		switch {
+		case bytes.HasPrefix(rest, []byte("<!--")):
+			i += skipPast(rest, "-->")
+		case bytes.HasPrefix(rest, []byte("<![CDATA[")):
+			i += skipPast(rest, "]]>")
+		case bytes.HasPrefix(rest, []byte("<?")):
+			i += skipPast(rest, "?>")
+		case bytes.HasPrefix(rest, []byte("<!DOCTYPE")):
+			i += w.entities.scanDoctype(rest)
+		case bytes.HasPrefix(rest, []byte("<!")):
+			i += skipTag(rest)
+		case bytes.HasPrefix(rest, []byte("</")):
+			i += skipTag(rest)
+			if len(scopes) > 0 {
+				ns = ns[:scopes[len(scopes)-1]]
+				scopes = scopes[:len(scopes)-1]
+			}
+		default:
+			n := skipTag(rest)
+			tag := rest[:n]
+			scope := len(ns)
+
+			var err error
+			if ns, err = w.walkStartTag(tag, i, ns, replace); err != nil {
+				return nil, false, err
+			}
+			if bytes.HasSuffix(tag, []byte("/>")) {
+				ns = ns[:scope]
+			} else {
+				scopes = append(scopes, scope)
+			}
+			i += n
+		}
+	}
+
+	if !changed {
+		return data, false, nil
+	}
+	return append(out, data[last:]...), true, nil
+}
+
+// walkText rewrites the hrefs in the replacement text of the entity references in data[start:end], replacing
+// each reference whose replacement text changes
+func (w *hrefRewriter) walkText(data []byte, start, end int, ns []nsBinding, replace func(start, end int, value []byte)) error {
+	for i := start; i < end; {
+		amp := bytes.IndexByte(data[i:end], '&')
+		if amp < 0 {
+			return nil
+		}
+		refStart := i + amp
+		name, n := parseReference(data[refStart:end])
+		if n == 0 {
+			i = refStart + 1
+			continue
+		}
+		i = refStart + n
+
+		if _, ok := w.entities[name]; !ok || !w.hasMarkup(name, map[string]bool{}) {
+			continue
+		}
+		text, err := w.expand(name, map[string]bool{})
+		if err != nil {
+			return err
+		}
+		// The replacement text can't pop the bindings of the elements around the reference
+		rewritten, changed, err := w.walk(text, ns[:len(ns):len(ns)])
+		if err != nil {
+			return err
+		}
+		if changed {
+			replace(refStart, i, rewritten)
+		}
+	}
+	return nil
+}
+
+// walkStartTag rewrites the matching hrefs in tag, which starts at offset in the data being walked, if it's an
+// image element. It returns ns with any namespace bindings declared by the tag added.
+func (w *hrefRewriter) walkStartTag(tag []byte, offset int, ns []nsBinding, replace func(start, end int, value []byte)) ([]nsBinding, error) {
+	name, attrs := parseStartTag(tag)
+	for _, attr := range attrs {
+		if strings.HasPrefix(attr.name, "xmlns:") {
+			uri, err := w.attrValue(tag[attr.start:attr.end], map[string]bool{})
+			if err != nil {
+				return nil, err
+			}
+			ns = append(ns, nsBinding{prefix: strings.TrimPrefix(attr.name, "xmlns:"), uri: uri})
+		}
+	}
+
+	// Any element named image or feImage is treated as one, whatever its namespace
+	if _, local := splitName(name); local != "image" && local != "feImage" {
+		return ns, nil
+	}
+
+	for _, attr := range attrs {
+		prefix, local := splitName(attr.name)
+		if local != "href" || (prefix != "" && lookupNamespace(ns, prefix) != xlinkNamespace) {
+			continue
+		}
+
+		href, err := w.attrValue(tag[attr.start:attr.end], map[string]bool{})
+		if err != nil {
+			return nil, err
+		}
+		if !w.match(href) {
+			continue
+		}
+		value, err := w.rewrite(href)
+		if err != nil {
+			return nil, err
+		}
+		if value != href {
+			replace(offset+attr.start, offset+attr.end, []byte(html.EscapeString(value)))
+		}
+	}
+	return ns, nil
+}
+
+// attrValue returns the value of an attribute written as raw, with its references replaced and whitespace
+// normalized as an XML parser would
+func (w *hrefRewriter) attrValue(raw []byte, visiting map[string]bool) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(raw); {
+		c := raw[i]
+		if c != '&' {
+			if isSpace(c) {
+				c = ' '
+			}
+			b.WriteByte(c)
+			i++
+			continue
+		}
+
+		semi := bytes.IndexByte(raw[i:], ';')
+		if semi < 0 {
+			b.Write(raw[i:])
+			break
+		}
+		ref := string(raw[i+1 : i+semi])
+		if r, ok := charRef(ref); ok {
+			b.WriteRune(r)
+		} else if text, ok := predefinedEntities[ref]; ok {
+			b.WriteString(text)
+		} else if value, ok := w.entities[ref]; ok {
+			if visiting[ref] {
+				return "", fmt.Errorf("%w: entity %s refers to itself", ErrEntityExpansion, ref)
+			}
+			if err := w.addExpansion(len(value)); err != nil {
+				return "", err
+			}
+			visiting[ref] = true
+			text, err := w.attrValue(expandCharRefs(value), visiting)
+			delete(visiting, ref)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(text)
+		} else {
+			b.Write(raw[i : i+semi+1])
+		}
+		i += semi + 1
+	}
+	return b.String(), nil
+}
+
+// expand returns the replacement text of the entity name, with the references in it to other declared
+// entities expanded
+func (w *hrefRewriter) expand(name string, visiting map[string]bool) ([]byte, error) {
+	if visiting[name] {
+		return nil, fmt.Errorf("%w: entity %s refers to itself", ErrEntityExpansion, name)
+	}
+	visiting[name] = true
+	defer delete(visiting, name)
+
+	text := expandCharRefs(w.entities[name])
+	if err := w.addExpansion(len(text)); err != nil {
+		return nil, err
+	}
+
+	var out []byte
+	last := 0
+	for i := 0; i < len(text); {
+		amp := bytes.IndexByte(text[i:], '&')
+		if amp < 0 {
+			break
+		}
+		refStart := i + amp
+		ref, n := parseReference(text[refStart:])
+		if n == 0 {
+			i = refStart + 1
+			continue
+		}
+		i = refStart + n
+		if _, ok := w.entities[ref]; !ok {
+			continue
+		}
+
+		sub, err := w.expand(ref, visiting)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, text[last:refStart]...)
+		out = append(out, sub...)
+		last = i
+	}
+	return append(out, text[last:]...), nil
+}
+
+// hasMarkup reports whether the replacement text of the entity name may contain elements, either directly
+// or through the entities it refers to
+func (w *hrefRewriter) hasMarkup(name string, visiting map[string]bool) bool {
+	value, ok := w.entities[name]
+	if !ok || visiting[name] {
+		return false
+	}
+	if markup, ok := w.markup[name]; ok {
+		return markup
+	}
+	visiting[name] = true
+	defer delete(visiting, name)
+
+	text := expandCharRefs(value)
+	markup := bytes.IndexByte(text, '<') >= 0
+	forEachReference(text, func(ref string) {
+		markup = markup || w.hasMarkup(ref, visiting)
+	})
+
+	if w.markup == nil {
+		w.markup = map[string]bool{}
+	}
+	w.markup[name] = markup
+	return markup
+}
+
+// addExpansion adds n bytes to the replacement text expanded so far, failing if there's too much to check
+func (w *hrefRewriter) addExpansion(n int) error {
+	w.expanded += int64(n)
+	if w.expanded > maxHrefExpansion {
+		return fmt.Errorf("%w: more than %d bytes to check for external references", ErrEntityExpansion, maxHrefExpansion)
+	}
+	return nil
+}
+
+// parseStartTag returns the name and attributes of the start tag at the start of tag. It stops at anything
+// that isn't well-formed, which resvg would reject anyway.
+func parseStartTag(tag []byte) (string, []tagAttr) {
+	i := 1
+	for i < len(tag) && !isSpace(tag[i]) && tag[i] != '/' && tag[i] != '>' {
+		i++
+	}
+	name := string(tag[1:i])
+
+	var attrs []tagAttr
+	for {
+		i = skipSpace(tag, i)
+		start := i
+		for i < len(tag) && !isSpace(tag[i]) && tag[i] != '=' && tag[i] != '/' && tag[i] != '>' {
+			i++
+		}
+		if i == start {
+			return name, attrs
+		}
+		attrName := string(tag[start:i])
+
+		i = skipSpace(tag, i)
+		if i >= len(tag) || tag[i] != '=' {
+			return name, attrs
+		}
+		i = skipSpace(tag, i+1)
+		if i >= len(tag) || (tag[i] != '"' && tag[i] != '\'') {
+			return name, attrs
+		}
+		n := skipQuoted(tag[i:])
+		if n < 2 || tag[i+n-1] != tag[i] {
+			return name, attrs
+		}
+		attrs = append(attrs, tagAttr{name: attrName, start: i + 1, end: i + n - 1})
+		i += n
+	}
+}
+
+// splitName splits a qualified name like "xlink:href" into its prefix and local name
+func splitName(name string) (prefix, local string) {
+	if colon := strings.IndexByte(name, ':'); colon >= 0 {
+		return name[:colon], name[colon+1:]
+	}
+	return "", name
+}
+
+// lookupNamespace returns the namespace URI bound to prefix by the innermost binding in ns
+func lookupNamespace(ns []nsBinding, prefix string) string {
+	for i := len(ns) - 1; i >= 0; i-- {
+		if ns[i].prefix == prefix {
+			return ns[i].uri
+		}
+	}
+	return ""
+}
+
+// parseReference returns the name of the entity reference, like "&name;", at the start of data and its
+// length. If there's no entity reference there, including if it's a character reference, n is 0.
+func parseReference(data []byte) (name string, n int) {
+	semi := bytes.IndexByte(data, ';')
+	if semi < 2 || data[1] == '#' || bytes.IndexAny(data[1:semi], " \t\r\n&<") >= 0 {
+		return "", 0
+	}
+	return string(data[1:semi]), semi + 1
+}
+
+// expandCharRefs returns value with its character references, like "&#60;", replaced by the characters
+// they refer to, as is done to the value of an entity when it's declared
+func expandCharRefs(value []byte) []byte {
+	if !bytes.Contains(value, []byte("&#")) {
+		return value
+	}
+
+	out := make([]byte, 0, len(value))
+	for i := 0; i < len(value); {
+		if value[i] == '&' {
+			if semi := bytes.IndexByte(value[i:], ';'); semi > 0 {
+				if r, ok := charRef(string(value[i+1 : i+semi])); ok {
+					out = utf8.AppendRune(out, r)
+					i += semi + 1
+					continue
+				}
+			}
+		}
+		out = append(out, value[i])
+		i++
+	}
+	return out
+}
+
+// charRef returns the character that a character reference like "#60" or "#x3C", without its '&' and ';',
+// refers to
+func charRef(ref string) (rune, bool) {
+	if !strings.HasPrefix(ref, "#") {
+		return 0, false
+	}
+	var (
+		n   uint64
+		err error
+	)
+	if strings.HasPrefix(ref, "#x") {
+		n, err = strconv.ParseUint(ref[2:], 16, 32)
+	} else {
+		n, err = strconv.ParseUint(ref[1:], 10, 32)
+	}
+	if err != nil || !utf8.ValidRune(rune(n)) {
+		return 0, false
+	}
+	return rune(n), true
+}
@@ -0,0 +1,130 @@
+package resvg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRewriteHrefs(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{
+			"unprefixed",
+			`<image href="a.png"/>`,
+			`<image href="[a.png]"/>`,
+		},
+		{
+			"xlink prefix",
+			`<svg xmlns:xlink="http://www.w3.org/1999/xlink"><image xlink:href='a.png'/></svg>`,
+			`<svg xmlns:xlink="http://www.w3.org/1999/xlink"><image xlink:href='[a.png]'/></svg>`,
+		},
+		{
+			"other prefix bound to xlink",
+			`<svg xmlns:l="http://www.w3.org/1999/xlink"><image l:href="a.png"/></svg>`,
+			`<svg xmlns:l="http://www.w3.org/1999/xlink"><image l:href="[a.png]"/></svg>`,
+		},
+		{
+			"binding on the same element",
+			`<image x:href="a.png" xmlns:x="http://www.w3.org/1999/xlink"/>`,
+			`<image x:href="[a.png]" xmlns:x="http://www.w3.org/1999/xlink"/>`,
+		},
+		{
+			"xlink prefix bound elsewhere",
+			`<svg xmlns:xlink="urn:other"><image xlink:href="a.png"/></svg>`,
+			`<svg xmlns:xlink="urn:other"><image xlink:href="a.png"/></svg>`,
+		},
+		{
+			"binding out of scope",
+			`<g xmlns:l="http://www.w3.org/1999/xlink"/><image l:href="a.png"/>`,
+			`<g xmlns:l="http://www.w3.org/1999/xlink"/><image l:href="a.png"/>`,
+		},
+		{
+			"prefixed element",
+			`<svg:feImage href="a.png"/>`,
+			`<svg:feImage href="[a.png]"/>`,
+		},
+		{
+			"angle bracket in attribute",
+			`<image title="a > b" href="a.png"/>`,
+			`<image title="a > b" href="[a.png]"/>`,
+		},
+		{
+			"references in attribute",
+			`<image href="a&amp;b&#46;png"/>`,
+			`<image href="[a&amp;b.png]"/>`,
+		},
+		{
+			"entity in attribute",
+			`<!DOCTYPE svg [<!ENTITY f "a.png">]><image href="&f;"/>`,
+			`<!DOCTYPE svg [<!ENTITY f "a.png">]><image href="[a.png]"/>`,
+		},
+		{
+			"entity with markup",
+			`<!DOCTYPE svg [<!ENTITY i "<image href='a.png'/>">]><g>&i;</g>`,
+			`<!DOCTYPE svg [<!ENTITY i "<image href='a.png'/>">]><g><image href='[a.png]'/></g>`,
+		},
+		{
+			"nested entities",
+			`<!DOCTYPE svg [<!ENTITY f "a.png"><!ENTITY i "<image href='&f;'/>"><!ENTITY j "&i;&i;">]><g>&j;</g>`,
+			`<!DOCTYPE svg [<!ENTITY f "a.png"><!ENTITY i "<image href='&f;'/>"><!ENTITY j "&i;&i;">]><g><image href='[a.png]'/><image href='[a.png]'/></g>`,
+		},
+		{
+			"entity without images",
+			`<!DOCTYPE svg [<!ENTITY r "<rect/>">]><g>&r;</g>`,
+			`<!DOCTYPE svg [<!ENTITY r "<rect/>">]><g>&r;</g>`,
+		},
+		{
+			"comments and CDATA",
+			`<!-- <image href="a.png"/> --><style><![CDATA[<image href="a.png"/>]]></style>`,
+			`<!-- <image href="a.png"/> --><style><![CDATA[<image href="a.png"/>]]></style>`,
+		},
+		{
+			"other elements and local references",
+			`<use href="a.svg"/><image href="#a"/><image href="data:image/png;base64,AAAA"/>`,
+			`<use href="a.svg"/><image href="#a"/><image href="data:image/png;base64,AAAA"/>`,
+		},
+	}
+
+	for _, test := range tests {
+		out, err := rewriteHrefs([]byte(test.doc), func(href string) (string, error) {
+			return "[" + href + "]", nil
+		})
+		if err != nil {
+			t.Errorf("%s: rewriteHrefs failed: %v", test.name, err)
+			continue
+		}
+		if string(out) != test.want {
+			t.Errorf("%s: got\n%s\nexpected\n%s", test.name, out, test.want)
+		}
+	}
+}
+
+func TestRewriteHrefsEntityExpansion(t *testing.T) {
+	identity := func(href string) (string, error) { return href, nil }
+
+	// Entities that expand without limit can't be checked
+	recursive := `<!DOCTYPE svg [<!ENTITY a "<image href='a.png'/>&b;"><!ENTITY b "&a;">]><g>&a;</g>`
+	if _, err := rewriteHrefs([]byte(recursive), identity); !errors.Is(err, ErrEntityExpansion) {
+		t.Errorf("Expected ErrEntityExpansion for a recursive entity, got %v", err)
+	}
+
+	bomb := `<!DOCTYPE svg [<!ENTITY a "<image href='a.png'/>` + strings.Repeat("x", 1000) + `">`
+	prev := "a"
+	for _, name := range []string{"b", "c", "d", "e", "f", "g"} {
+		bomb += `<!ENTITY ` + name + ` "` + strings.Repeat("&"+prev+";", 10) + `">`
+		prev = name
+	}
+	bomb += `]><g>&g;</g>`
+	if _, err := rewriteHrefs([]byte(bomb), identity); !errors.Is(err, ErrEntityExpansion) {
+		t.Errorf("Expected ErrEntityExpansion for an entity bomb, got %v", err)
+	}
+
+	// Entities without markup are never expanded, however large they would be
+	if _, err := rewriteHrefs([]byte(billionLaughs), identity); err != nil {
+		t.Errorf("rewriteHrefs failed: %v", err)
+	}
+}
@@ -0,0 +1,503 @@
+package resvg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+)
+
+// Limits protects against hostile input by bounding the documents that can be parsed. They are checked by
+// a quick scan of the document before it's passed to resvg. A limit of 0 means no limit.
+type Limits struct {
+	// MaxBytes is the largest input, before any decompression, in bytes. Readers and files are read no
+	// further than this. Exceeding it fails with ErrInputTooLarge.
+	MaxBytes int64
+
+	// MaxDecompressedBytes is the largest size, in bytes, that SVGZ input may decompress to.
+	// Exceeding it fails with ErrDecompressedTooLarge.
+	MaxDecompressedBytes int64
+
+	// MaxDepth is the deepest elements may be nested, including elements in the replacement text of entities.
+	// Exceeding it fails with ErrTooDeep.
+	MaxDepth int
+
+	// MaxElements is the most elements the document may contain, including elements in the replacement text of
+	// entities. Exceeding it fails with ErrTooManyElements.
+	MaxElements int
+
+	// MaxEntityExpansion is the most bytes that references to entities declared in the document's DTD may
+	// expand to in total. Exceeding it, or declaring an entity that refers to itself, fails with
+	// ErrEntityExpansion.
+	MaxEntityExpansion int64
+}
+
+// SetLimits sets the limits that documents parsed with these options must be within
+func (o *Options) SetLimits(limits Limits) error {
+	if err := o.acquire(); err != nil {
+		return err
+	}
+	defer o.release()
+	o.settingsMu.Lock()
+	defer o.settingsMu.Unlock()
+
+	o.limits = limits
+	return nil
+}
+
+// currentLimits returns the limits set on o
+func (o *Options) currentLimits() (Limits, error) {
+	if err := o.acquire(); err != nil {
+		return Limits{}, err
+	}
+	defer o.release()
+	o.settingsMu.RLock()
+	defer o.settingsMu.RUnlock()
+
+	return o.limits, nil
+}
+
+// maxEmbeddedSVG is how deeply SVG images embedded in data: URIs are checked. resvg itself only loads the
+// images in the top-level document, so anything deeper is never parsed.
+const maxEmbeddedSVG = 4
+
+// check scans the decompressed document in data, returning an error if it exceeds any of the structural limits.
+// SVG images embedded in the document as data: URIs are scanned too, counting toward the same limits.
+func (l Limits) check(data []byte) error {
+	if l.MaxDecompressedBytes <= 0 && l.MaxDepth <= 0 && l.MaxElements <= 0 && l.MaxEntityExpansion <= 0 {
+		return nil
+	}
+	s := limitScanner{limits: l}
+	return s.checkDocument(data, 0)
+}
+
+// limitScanner tracks the state of a scan of a document for Limits.check. It only understands as much
+// XML as is needed to count elements and entity references; malformed documents are left for resvg to reject.
+type limitScanner struct {
+	limits Limits
+
+	depth     int
+	elements  int
+	expansion int64
+
+	// entities holds the general entities declared in the DTD
+	entities entityDecls
+
+	// expansions caches what each entity expands to
+	expansions map[string]entityExpansion
+}
+
+// entityExpansion is what a reference to an entity expands to once all the references in it are expanded
+type entityExpansion struct {
+	// size is the length of the replacement text, in bytes
+	size int64
+
+	// elements is the number of elements in the replacement text
+	elements int64
+
+	// depth is how deeply the elements in the replacement text nest, relative to the reference
+	depth int
+}
+
+// checkDocument scans the document in data, and then the SVG images embedded in it, which are nested levels
+// deep. Each document has its own entities and depth, but elements and entity expansion are counted across
+// all of them.
+func (s *limitScanner) checkDocument(data []byte, nested int) error {
+	s.depth = 0
+	s.entities = entityDecls{}
+	s.expansions = nil
+	if err := s.scan(data); err != nil {
+		return err
+	}
+	if nested == maxEmbeddedSVG || !mayHaveDataHrefs(data) {
+		return nil
+	}
+
+	hrefs, err := dataHrefs(data)
+	if err != nil {
+		return err
+	}
+	for _, href := range hrefs {
+		svg, ok := embeddedSVG(href)
+		if !ok {
+			continue
+		}
+		svg, err := decompress(svg, s.limits.MaxDecompressedBytes)
+		if errors.Is(err, ErrMalformedGzip) {
+			// resvg fails to load the image, so there's nothing to check
+			continue
+		} else if err != nil {
+			return err
+		}
+		if err := s.checkDocument(svg, nested+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mayHaveDataHrefs reports whether data may contain a data: URI. Without any references, which could hide
+// it, a data: URI has to appear literally.
+func mayHaveDataHrefs(data []byte) bool {
+	if bytes.IndexByte(data, '&') >= 0 {
+		return true
+	}
+	for i := 0; i < len(data); {
+		colon := bytes.IndexByte(data[i:], ':')
+		if colon < 0 {
+			return false
+		}
+		i += colon + 1
+		if i >= 5 && bytes.EqualFold(data[i-5:i-1], []byte("data")) {
+			return true
+		}
+	}
+	return false
+}
+
+// embeddedSVG returns the SVG document in the data: URI href, if resvg would load it as one
+func embeddedSVG(href string) ([]byte, bool) {
+	mediaType, content, ok := decodeDataURI(href)
+	if !ok || len(content) == 0 {
+		return nil, false
+	}
+	switch mediaType {
+	case "image/svg+xml":
+		return content, true
+	case "text/plain":
+		// resvg loads text/plain data as SVG unless it looks like a raster image
+		switch http.DetectContentType(content) {
+		case "image/png", "image/jpeg", "image/gif", "image/webp":
+			return nil, false
+		}
+		return content, true
+	}
+	return nil, false
+}
+
+func (s *limitScanner) scan(data []byte) error {
+	i := 0
+	for i < len(data) {
+		lt := bytes.IndexByte(data[i:], '<')
+		if lt < 0 {
+			return s.countReferences(data[i:], true)
+		}
+		if err := s.countReferences(data[i:i+lt], true); err != nil {
+			return err
+		}
+		i += lt
+
+		rest := data[i:]
+		switch {
+		case bytes.HasPrefix(rest, []byte("<!--")):
+			i += skipPast(rest, "-->")
+		case bytes.HasPrefix(rest, []byte("<![CDATA[")):
+			i += skipPast(rest, "]]>")
+		case bytes.HasPrefix(rest, []byte("<?")):
+			i += skipPast(rest, "?>")
+		case bytes.HasPrefix(rest, []byte("<!DOCTYPE")):
+			i += s.entities.scanDoctype(rest)
+		case bytes.HasPrefix(rest, []byte("<!")):
+			i += skipTag(rest)
+		case bytes.HasPrefix(rest, []byte("</")):
+			i += skipTag(rest)
+			if s.depth > 0 {
+				s.depth--
+			}
+		default:
+			n := skipTag(rest)
+			tag := rest[:n]
+			i += n
+
+			s.elements++
+			if s.limits.MaxElements > 0 && s.elements > s.limits.MaxElements {
+				return fmt.Errorf("%w: more than %d", ErrTooManyElements, s.limits.MaxElements)
+			}
+			if !bytes.HasSuffix(tag, []byte("/>")) {
+				s.depth++
+				if s.limits.MaxDepth > 0 && s.depth > s.limits.MaxDepth {
+					return fmt.Errorf("%w: more than %d levels", ErrTooDeep, s.limits.MaxDepth)
+				}
+			}
+			if err := s.countReferences(tag, false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// entityDecls holds the general entities declared in a document's DTD, mapping their names to their values
+// as written in their declarations
+type entityDecls map[string][]byte
+
+// scanDoctype records the entities declared in the document type declaration at the start of data,
+// returning its length
+func (e entityDecls) scanDoctype(data []byte) int {
+	i := len("<!DOCTYPE")
+	for i < len(data) {
+		switch c := data[i]; c {
+		case '"', '\'':
+			i += skipQuoted(data[i:])
+		case '[':
+			i += 1 + e.scanInternalSubset(data[i+1:])
+		case '>':
+			return i + 1
+		default:
+			i++
+		}
+	}
+	return len(data)
+}
+
+// scanInternalSubset records the entity declarations in the internal subset at the start of data,
+// returning its length, including the closing ']'
+func (e entityDecls) scanInternalSubset(data []byte) int {
+	i := 0
+	for i < len(data) {
+		rest := data[i:]
+		switch {
+		case rest[0] == ']':
+			return i + 1
+		case rest[0] == '"' || rest[0] == '\'':
+			i += skipQuoted(rest)
+		case bytes.HasPrefix(rest, []byte("<!--")):
+			i += skipPast(rest, "-->")
+		case bytes.HasPrefix(rest, []byte("<!ENTITY")):
+			i += e.scanEntityDecl(rest)
+		default:
+			i++
+		}
+	}
+	return len(data)
+}
+
+// scanEntityDecl records the entity declared at the start of data, returning the length of the declaration.
+// Parameter entities and external entities are skipped, since resvg doesn't expand them.
+func (e entityDecls) scanEntityDecl(data []byte) int {
+	i := skipSpace(data, len("<!ENTITY"))
+	if i < len(data) && data[i] == '%' {
+		return skipTag(data)
+	}
+
+	start := i
+	for i < len(data) && !isSpace(data[i]) && data[i] != '>' {
+		i++
+	}
+	name := string(data[start:i])
+	i = skipSpace(data, i)
+
+	if i < len(data) && (data[i] == '"' || data[i] == '\'') {
+		n := skipQuoted(data[i:])
+		if n >= 2 && name != "" {
+			// The first declaration of an entity is the one that's used
+			if _, ok := e[name]; !ok {
+				e[name] = data[i+1 : i+n-1]
+			}
+		}
+		i += n
+	}
+	return i + skipToTagEnd(data[i:])
+}
+
+// countReferences adds what the entity references in data expand to to the totals. Only references in
+// content, rather than in attribute values, can add elements.
+func (s *limitScanner) countReferences(data []byte, content bool) error {
+	if len(s.entities) == 0 {
+		return nil
+	}
+
+	var err error
+	forEachReference(data, func(name string) {
+		if err != nil {
+			return
+		}
+		if _, ok := s.entities[name]; !ok {
+			return
+		}
+		expansion := s.expand(name, map[string]bool{})
+
+		s.expansion = addSaturating(s.expansion, expansion.size)
+		if s.limits.MaxEntityExpansion > 0 && s.expansion > s.limits.MaxEntityExpansion {
+			err = fmt.Errorf("%w: more than %d bytes", ErrEntityExpansion, s.limits.MaxEntityExpansion)
+			return
+		}
+		if !content {
+			return
+		}
+
+		s.elements = int(addSaturating(int64(s.elements), expansion.elements))
+		if s.limits.MaxElements > 0 && s.elements > s.limits.MaxElements {
+			err = fmt.Errorf("%w: more than %d", ErrTooManyElements, s.limits.MaxElements)
+			return
+		}
+		if s.limits.MaxDepth > 0 && s.depth+expansion.depth > s.limits.MaxDepth {
+			err = fmt.Errorf("%w: more than %d levels", ErrTooDeep, s.limits.MaxDepth)
+		}
+	})
+	return err
+}
+
+// expand returns what the entity name expands to. An entity that refers to itself, directly or not, expands
+// forever, so its size is math.MaxInt64.
+func (s *limitScanner) expand(name string, visiting map[string]bool) entityExpansion {
+	if expansion, ok := s.expansions[name]; ok {
+		return expansion
+	}
+	if visiting[name] {
+		return entityExpansion{size: math.MaxInt64}
+	}
+	visiting[name] = true
+	defer delete(visiting, name)
+
+	// Character references are replaced when the entity is declared, so "&#60;g/>" is an element
+	text := expandCharRefs(s.entities[name])
+	expansion := entityExpansion{size: int64(len(text))}
+	depth := 0
+	add := func(data []byte, content bool) {
+		forEachReference(data, func(ref string) {
+			if _, ok := s.entities[ref]; !ok {
+				return
+			}
+			sub := s.expand(ref, visiting)
+			expansion.size = addSaturating(expansion.size, sub.size)
+			if content {
+				expansion.elements = addSaturating(expansion.elements, sub.elements)
+				expansion.depth = maxInt(expansion.depth, depth+sub.depth)
+			}
+		})
+	}
+
+	for i := 0; i < len(text); {
+		lt := bytes.IndexByte(text[i:], '<')
+		if lt < 0 {
+			add(text[i:], true)
+			break
+		}
+		add(text[i:i+lt], true)
+		i += lt
+
+		rest := text[i:]
+		switch {
+		case bytes.HasPrefix(rest, []byte("<!--")):
+			i += skipPast(rest, "-->")
+		case bytes.HasPrefix(rest, []byte("<![CDATA[")):
+			i += skipPast(rest, "]]>")
+		case bytes.HasPrefix(rest, []byte("<?")):
+			i += skipPast(rest, "?>")
+		case bytes.HasPrefix(rest, []byte("</")):
+			i += skipTag(rest)
+			if depth > 0 {
+				depth--
+			}
+		default:
+			n := skipTag(rest)
+			tag := rest[:n]
+			i += n
+
+			expansion.elements = addSaturating(expansion.elements, 1)
+			if !bytes.HasSuffix(tag, []byte("/>")) {
+				depth++
+				expansion.depth = maxInt(expansion.depth, depth)
+			}
+			add(tag, false)
+		}
+	}
+
+	if s.expansions == nil {
+		s.expansions = map[string]entityExpansion{}
+	}
+	s.expansions[name] = expansion
+	return expansion
+}
+
+// forEachReference calls f with the name of each entity reference, like "&name;", in data.
+// Character references, like "&#60;", are skipped.
+func forEachReference(data []byte, f func(name string)) {
+	for {
+		amp := bytes.IndexByte(data, '&')
+		if amp < 0 {
+			return
+		}
+		data = data[amp+1:]
+
+		semi := bytes.IndexByte(data, ';')
+		if semi < 0 {
+			return
+		}
+		name := data[:semi]
+		if len(name) > 0 && name[0] != '#' && bytes.IndexAny(name, " \t\r\n&<") < 0 {
+			f(string(name))
+			data = data[semi+1:]
+		}
+	}
+}
+
+// skipPast returns the length of data up to and including the first occurrence of end, or len(data) if
+// there is none
+func skipPast(data []byte, end string) int {
+	i := bytes.Index(data, []byte(end))
+	if i < 0 {
+		return len(data)
+	}
+	return i + len(end)
+}
+
+// skipTag returns the length of the tag at the start of data, up to and including its closing '>',
+// skipping over quoted attribute values
+func skipTag(data []byte) int {
+	return 1 + skipToTagEnd(data[1:])
+}
+
+// skipToTagEnd returns the length of data up to and including the next '>' outside of quotes
+func skipToTagEnd(data []byte) int {
+	i := 0
+	for i < len(data) {
+		switch c := data[i]; c {
+		case '"', '\'':
+			i += skipQuoted(data[i:])
+		case '>':
+			return i + 1
+		default:
+			i++
+		}
+	}
+	return len(data)
+}
+
+// skipQuoted returns the length of the quoted string at the start of data, including the quotes
+func skipQuoted(data []byte) int {
+	end := bytes.IndexByte(data[1:], data[0])
+	if end < 0 {
+		return len(data)
+	}
+	return end + 2
+}
+
+// skipSpace returns the index of the first non-space byte in data at or after i
+func skipSpace(data []byte, i int) int {
+	for i < len(data) && isSpace(data[i]) {
+		i++
+	}
+	return i
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// addSaturating returns a + b, or math.MaxInt64 if that would overflow. Both must be non-negative.
+func addSaturating(a, b int64) int64 {
+	if a > math.MaxInt64-b {
+		return math.MaxInt64
+	}
+	return a + b
+}
@@ -0,0 +1,220 @@
+package resvg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const billionLaughs = `<?xml version="1.0"?>
+<!DOCTYPE svg [
+	<!ENTITY lol "lol">
+	<!ENTITY lol1 "&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;">
+	<!ENTITY lol2 "&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;">
+	<!ENTITY lol3 "&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;">
+	<!ENTITY lol4 "&lol3;&lol3;&lol3;&lol3;&lol3;&lol3;&lol3;&lol3;&lol3;&lol3;">
+	<!ENTITY lol5 "&lol4;&lol4;&lol4;&lol4;&lol4;&lol4;&lol4;&lol4;&lol4;&lol4;">
+	<!ENTITY lol6 "&lol5;&lol5;&lol5;&lol5;&lol5;&lol5;&lol5;&lol5;&lol5;&lol5;">
+	<!ENTITY lol7 "&lol6;&lol6;&lol6;&lol6;&lol6;&lol6;&lol6;&lol6;&lol6;&lol6;">
+	<!ENTITY lol8 "&lol7;&lol7;&lol7;&lol7;&lol7;&lol7;&lol7;&lol7;&lol7;&lol7;">
+	<!ENTITY lol9 "&lol8;&lol8;&lol8;&lol8;&lol8;&lol8;&lol8;&lol8;&lol8;&lol8;">
+]>
+<svg xmlns="http://www.w3.org/2000/svg"><text>&lol9;</text></svg>`
+
+func nestedSVG(depth int) string {
+	return `<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10">` +
+		strings.Repeat("<g>", depth) + `<rect width="10" height="10"/>` + strings.Repeat("</g>", depth) +
+		`</svg>`
+}
+
+// svgDataURI embeds doc in an image element as a base64 data: URI
+func svgDataURI(doc []byte) string {
+	return `<svg xmlns="http://www.w3.org/2000/svg"><image href="data:image/svg+xml;base64,` +
+		base64.StdEncoding.EncodeToString(doc) + `"/></svg>`
+}
+
+func TestLimitsCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits Limits
+		doc    string
+		err    error
+	}{
+		{"no limits", Limits{}, billionLaughs, nil},
+		{"depth within limit", Limits{MaxDepth: 11}, nestedSVG(10), nil},
+		{"depth over limit", Limits{MaxDepth: 10}, nestedSVG(10), ErrTooDeep},
+		{"elements within limit", Limits{MaxElements: 12}, nestedSVG(10), nil},
+		{"elements over limit", Limits{MaxElements: 11}, nestedSVG(10), ErrTooManyElements},
+		{"self-closing elements don't nest", Limits{MaxDepth: 2}, `<svg>` + strings.Repeat(`<rect/>`, 100) + `</svg>`, nil},
+		{"comments and CDATA aren't elements", Limits{MaxElements: 1},
+			`<svg><!-- <g><g> --><style><![CDATA[ <g><g> ]]></style></svg>`, ErrTooManyElements},
+		{"comments and CDATA aren't elements within limit", Limits{MaxElements: 2},
+			`<svg><!-- <g><g> --><style><![CDATA[ <g><g> ]]></style></svg>`, nil},
+		{"quoted > in attributes", Limits{MaxDepth: 1}, `<svg><rect title="a>b"/></svg>`, nil},
+		{"billion laughs", Limits{MaxEntityExpansion: 1 << 20}, billionLaughs, ErrEntityExpansion},
+		{"small entity expansion", Limits{MaxEntityExpansion: 100},
+			`<!DOCTYPE svg [<!ENTITY a "abc">]><svg><text>&a;&a;</text></svg>`, nil},
+		{"entity in attribute", Limits{MaxEntityExpansion: 5},
+			`<!DOCTYPE svg [<!ENTITY a "abc">]><svg><text x="&a;&a;">1</text></svg>`, ErrEntityExpansion},
+		{"recursive entity", Limits{MaxEntityExpansion: 1 << 20},
+			`<!DOCTYPE svg [<!ENTITY a "&b;"><!ENTITY b "&a;">]><svg><text>&a;</text></svg>`, ErrEntityExpansion},
+		{"predefined entities aren't counted", Limits{MaxEntityExpansion: 1},
+			`<svg><text>&lt;&amp;&gt;&#60;</text></svg>`, nil},
+		{"elements in entities", Limits{MaxElements: 10},
+			`<!DOCTYPE svg [<!ENTITY e "<g/><g/><g/><g/><g/>">]><svg>&e;&e;&e;</svg>`, ErrTooManyElements},
+		{"elements in entities within limit", Limits{MaxElements: 16},
+			`<!DOCTYPE svg [<!ENTITY e "<g/><g/><g/><g/><g/>">]><svg>&e;&e;&e;</svg>`, nil},
+		{"elements in nested entities", Limits{MaxElements: 100},
+			`<!DOCTYPE svg [<!ENTITY a "<g/><g/><g/><g/><g/><g/><g/><g/><g/><g/>"><!ENTITY b "&a;&a;&a;&a;&a;&a;&a;&a;&a;&a;">]>` +
+				`<svg>&b;</svg>`, ErrTooManyElements},
+		{"elements in character references", Limits{MaxElements: 2},
+			`<!DOCTYPE svg [<!ENTITY e "&#60;g/>&#60;g/>">]><svg>&e;</svg>`, ErrTooManyElements},
+		{"elements in attribute entities aren't counted", Limits{MaxElements: 2},
+			`<!DOCTYPE svg [<!ENTITY e "&lt;g/>&lt;g/>">]><svg><text x="&e;">1</text></svg>`, nil},
+		{"depth in entities", Limits{MaxDepth: 3},
+			`<!DOCTYPE svg [<!ENTITY d "<g><g><rect/></g></g>">]><svg><g>&d;</g></svg>`, ErrTooDeep},
+		{"depth in entities within limit", Limits{MaxDepth: 4},
+			`<!DOCTYPE svg [<!ENTITY d "<g><g><rect/></g></g>">]><svg><g>&d;</g></svg>`, nil},
+		{"depth in nested entities", Limits{MaxDepth: 3},
+			`<!DOCTYPE svg [<!ENTITY a "<g><rect/></g>"><!ENTITY b "<g><g>&a;</g></g>">]><svg>&b;</svg>`, ErrTooDeep},
+		{"entity bomb in data URI", Limits{MaxEntityExpansion: 1 << 20}, svgDataURI([]byte(billionLaughs)), ErrEntityExpansion},
+		{"entity bomb in data URI in data URI", Limits{MaxEntityExpansion: 1 << 20},
+			svgDataURI([]byte(svgDataURI([]byte(billionLaughs)))), ErrEntityExpansion},
+		{"depth in percent-encoded data URI", Limits{MaxDepth: 100},
+			`<svg><image href="data:image/svg+xml,` + url.PathEscape(nestedSVG(200)) + `"/></svg>`, ErrTooDeep},
+		{"depth in data URI within limit", Limits{MaxDepth: 100}, svgDataURI([]byte(nestedSVG(90))), nil},
+		{"elements across data URIs", Limits{MaxElements: 15},
+			`<svg><g/><g/>` + svgDataURI([]byte(nestedSVG(10))) + `</svg>`, ErrTooManyElements},
+		{"data URI with xlink prefix and no media type", Limits{MaxDepth: 100},
+			`<svg xmlns:l="http://www.w3.org/1999/xlink"><image l:href="data:;base64,` +
+				base64.StdEncoding.EncodeToString([]byte(nestedSVG(200))) + `"/></svg>`, ErrTooDeep},
+		{"gzip bomb in data URI", Limits{MaxDecompressedBytes: 1024}, svgDataURI(gzipData(t, []byte(nestedSVG(1000)))),
+			ErrDecompressedTooLarge},
+		{"raster data URIs aren't scanned", Limits{MaxElements: 2},
+			`<svg><image href="data:image/png;base64,` + base64.StdEncoding.EncodeToString([]byte("<g><g><g>")) + `"/></svg>`, nil},
+		{"depth in nested entities within limit", Limits{MaxDepth: 4},
+			`<!DOCTYPE svg [<!ENTITY a "<g><rect/></g>"><!ENTITY b "<g><g>&a;</g></g>">]><svg>&b;</svg>`, nil},
+	}
+
+	for _, test := range tests {
+		err := test.limits.check([]byte(test.doc))
+		if test.err == nil && err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		}
+		if test.err != nil && !errors.Is(err, test.err) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.err, err)
+		}
+	}
+}
+
+// countingReader counts the bytes read from it
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func TestSetLimits(t *testing.T) {
+	opts := NewOptions()
+	defer opts.Close()
+
+	if err := opts.SetLimits(Limits{MaxBytes: 1024}); err != nil {
+		t.Fatalf("SetLimits failed: %v", err)
+	}
+
+	large := nestedSVG(1000)
+	_, err := ParseFromData([]byte(large), opts)
+	if !errors.Is(err, ErrInputTooLarge) {
+		t.Fatalf("Expected ErrInputTooLarge, got %v", err)
+	}
+
+	reader := &countingReader{r: strings.NewReader(large)}
+	_, err = ParseFromReader(reader, opts)
+	if !errors.Is(err, ErrInputTooLarge) {
+		t.Fatalf("Expected ErrInputTooLarge, got %v", err)
+	}
+	if reader.n > 1024+512 {
+		t.Fatalf("Expected reading to stop near the limit, read %d bytes", reader.n)
+	}
+
+	// Compressed, the document is within MaxBytes, but not MaxDecompressedBytes
+	compressed := gzipData(t, []byte(large))
+	if len(compressed) > 1024 {
+		t.Fatalf("Test document compressed to %d bytes", len(compressed))
+	}
+	if err := opts.SetLimits(Limits{MaxBytes: 1024, MaxDecompressedBytes: 1024}); err != nil {
+		t.Fatalf("SetLimits failed: %v", err)
+	}
+	_, err = ParseFromData(compressed, opts)
+	if !errors.Is(err, ErrDecompressedTooLarge) {
+		t.Fatalf("Expected ErrDecompressedTooLarge, got %v", err)
+	}
+
+	for _, test := range []struct {
+		limits Limits
+		doc    string
+		err    error
+	}{
+		{Limits{MaxDepth: 100}, nestedSVG(200), ErrTooDeep},
+		{Limits{MaxElements: 100}, nestedSVG(200), ErrTooManyElements},
+		{Limits{MaxEntityExpansion: 1 << 20}, billionLaughs, ErrEntityExpansion},
+	} {
+		if err := opts.SetLimits(test.limits); err != nil {
+			t.Fatalf("SetLimits failed: %v", err)
+		}
+		_, err := ParseFromReader(bytes.NewReader([]byte(test.doc)), opts)
+		if !errors.Is(err, test.err) {
+			t.Fatalf("%+v: expected %v, got %v", test.limits, test.err, err)
+		}
+		var resvgErr *Error
+		if !errors.As(err, &resvgErr) || resvgErr.Op != "parse" {
+			t.Fatalf("Expected *Error from parse, got %v", err)
+		}
+	}
+
+	// Images a resolver inlines are checked too
+	if err := opts.SetLimits(Limits{MaxEntityExpansion: 1 << 20}); err != nil {
+		t.Fatalf("SetLimits failed: %v", err)
+	}
+	if err := opts.SetResolver(MapResolver{"bomb.svg": []byte(billionLaughs)}); err != nil {
+		t.Fatalf("SetResolver failed: %v", err)
+	}
+	_, err = ParseFromData([]byte(`<svg xmlns="http://www.w3.org/2000/svg"><image href="bomb.svg"/></svg>`), opts)
+	if !errors.Is(err, ErrEntityExpansion) {
+		t.Fatalf("Expected ErrEntityExpansion from a resolved image, got %v", err)
+	}
+	if err := opts.SetResolver(nil); err != nil {
+		t.Fatalf("SetResolver failed: %v", err)
+	}
+
+	// Within the limits, documents parse as usual
+	if err := opts.SetLimits(Limits{MaxBytes: 1 << 20, MaxDepth: 100, MaxElements: 1000, MaxEntityExpansion: 1 << 20}); err != nil {
+		t.Fatalf("SetLimits failed: %v", err)
+	}
+	tree, err := ParseFromData([]byte(nestedSVG(50)), opts)
+	if err != nil {
+		t.Fatalf("ParseFromData failed: %v", err)
+	}
+	tree.Close()
+}
+
+func BenchmarkLimitsCheck(b *testing.B) {
+	doc := []byte(strings.Repeat(nestedSVG(20), 100))
+	limits := Limits{MaxDepth: 1000, MaxElements: 1 << 20, MaxEntityExpansion: 1 << 20}
+	b.SetBytes(int64(len(doc)))
+	for i := 0; i < b.N; i++ {
+		if err := limits.check(doc); err != nil {
+			b.Fatal(fmt.Sprint(err))
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package resvg
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// ParseFromReader reads an SVG or SVGZ document from r and parses it into a render tree
+func ParseFromReader(r io.Reader, opts *Options) (*RenderTree, error) {
+	limits, err := opts.currentLimits()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := readLimited(r, limits.MaxBytes)
+	if err != nil {
+		return nil, &Error{Op: "parse", Err: err}
+	}
+	return parseTree(context.Background(), data, opts, "", "", nil)
+}
+
+// ParseFromFS parses the SVG or SVGZ file name in fsys into a render tree. Unless opts has a Resolver,
+// images with relative paths are loaded from fsys, relative to the directory containing the file. Images
+// that aren't in fsys are left out, as resvg leaves out images it can't load.
+//
+// Nothing outside fsys is read: images with absolute paths, file: URLs or any other URL are left out
+// too, and the resources directory set on opts isn't used.
+func ParseFromFS(fsys fs.FS, name string, opts *Options) (*RenderTree, error) {
+	limits, err := opts.currentLimits()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, fileOpenError(name, err)
+	}
+	defer f.Close()
+
+	data, err := readLimited(f, limits.MaxBytes)
+	if errors.Is(err, ErrInputTooLarge) {
+		return nil, &Error{Op: "parse", Path: name, Err: err}
+	} else if err != nil {
+		return nil, fileOpenError(name, err)
+	}
+
+	// fs.Sub only fails for invalid directory names, which Open would have rejected
+	dirFS, err := fs.Sub(fsys, path.Dir(name))
+	if err != nil {
+		return nil, fileOpenError(name, err)
+	}
+	return parseTree(context.Background(), data, opts, name, "", dirFS)
+}
+
+// resolveRelativeHrefs rewrites the relative hrefs of the image elements in data into data: URIs, loading
+// them from fsys. Hrefs that aren't in fsys are removed rather than failing the parse, and so are all other
+// external hrefs, such as URLs and absolute paths, so that resvg never loads anything from outside fsys.
+func resolveRelativeHrefs(data []byte, fsys fs.FS) ([]byte, error) {
+	resolver := FSResolver{FS: fsys}
+	return rewriteHrefs(data, func(href string) (string, error) {
+		if !isRelativeHref(href) {
+			return "", nil
+		}
+		content, err := resolver.Resolve(href)
+		if err != nil {
+			return "", nil
+		}
+		return dataURI(content), nil
+	})
+}
+
+// isRelativeHref reports whether href is a relative path, rather than a URL or an absolute path
+func isRelativeHref(href string) bool {
+	href = strings.TrimSpace(href)
+	if strings.HasPrefix(href, "/") || strings.HasPrefix(href, `\`) || driveLetterPattern.MatchString(href) {
+		return false
+	}
+	u, err := url.Parse(href)
+	return err == nil && u.Scheme == ""
+}
+
+// readLimited reads all of r, returning an error wrapping ErrInputTooLarge if there's more than limit bytes.
+// A limit of 0 means no limit.
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		return io.ReadAll(r)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, inputTooLarge(limit)
+	}
+	return data, nil
+}
+
+// inputTooLarge returns the error for input over the limit of n bytes
+func inputTooLarge(n int64) error {
+	return fmt.Errorf("%w: more than %d bytes", ErrInputTooLarge, n)
+}
+
+// isGzip reports whether data starts with the gzip magic number
+func isGzip(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
+// decompress returns data with any gzip compression removed, so that SVGZ input is handled the same way
+// whichever function it's parsed with. If limit is more than 0, it's the most bytes the data may
+// decompress to.
+func decompress(data []byte, limit int64) ([]byte, error) {
+	if !isGzip(data) {
+		return data, nil
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedGzip, err)
+	}
+	defer zr.Close()
+
+	var r io.Reader = zr
+	if limit > 0 {
+		r = io.LimitReader(zr, limit+1)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedGzip, err)
+	}
+	if limit > 0 && int64(len(out)) > limit {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrDecompressedTooLarge, limit)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("%w: no data", ErrMalformedGzip)
+	}
+	return out, nil
+}
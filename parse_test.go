@@ -0,0 +1,281 @@
+package resvg
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const parseTestSVG = `<svg width="20" height="10" xmlns="http://www.w3.org/2000/svg">
+	<rect width="20" height="10" fill="red"/>
+</svg>`
+
+func gzipData(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatalf("gzip failed: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func checkParsedSize(t *testing.T, tree *RenderTree, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	defer tree.Close()
+
+	size, err := tree.GetImageSize()
+	if err != nil {
+		t.Fatalf("GetImageSize failed: %v", err)
+	}
+	if size.Width != 20 || size.Height != 10 {
+		t.Fatalf("Expected 20x10, got %vx%v", size.Width, size.Height)
+	}
+}
+
+func TestParseFromReader(t *testing.T) {
+	opts := NewOptions()
+	defer opts.Close()
+
+	tree, err := ParseFromReader(strings.NewReader(parseTestSVG), opts)
+	checkParsedSize(t, tree, err)
+
+	tree, err = ParseFromReader(bytes.NewReader(gzipData(t, []byte(parseTestSVG))), opts)
+	checkParsedSize(t, tree, err)
+
+	_, err = ParseFromReader(strings.NewReader(""), opts)
+	if !errors.Is(err, ErrEmptyData) {
+		t.Fatalf("Expected ErrEmptyData, got %v", err)
+	}
+}
+
+func TestParseFromFS(t *testing.T) {
+	opts := NewOptions()
+	defer opts.Close()
+
+	fsys := fstest.MapFS{
+		"images/test.svg":  {Data: []byte(parseTestSVG)},
+		"images/test.svgz": {Data: gzipData(t, []byte(parseTestSVG))},
+	}
+
+	tree, err := ParseFromFS(fsys, "images/test.svg", opts)
+	checkParsedSize(t, tree, err)
+
+	tree, err = ParseFromFS(fsys, "images/test.svgz", opts)
+	checkParsedSize(t, tree, err)
+
+	_, err = ParseFromFS(fsys, "images/missing.svg", opts)
+	if !errors.Is(err, ErrFileOpenFailed) {
+		t.Fatalf("Expected ErrFileOpenFailed, got %v", err)
+	}
+	var resvgErr *Error
+	if !errors.As(err, &resvgErr) || resvgErr.Path != "images/missing.svg" {
+		t.Fatalf("Expected *Error with path, got %v", err)
+	}
+}
+
+func TestParseFromFSRelativeImages(t *testing.T) {
+	opts := NewOptions()
+	defer opts.Close()
+
+	doc := `<svg width="4" height="4" xmlns="http://www.w3.org/2000/svg">
+		<image href="img/dot.png" width="4" height="4"/>
+		<image href="missing.png" width="4" height="4"/>
+	</svg>`
+	fsys := fstest.MapFS{
+		"icons/logo.svg":    {Data: []byte(doc)},
+		"icons/img/dot.png": {Data: encodeTestPNG(t, color.NRGBA{0, 255, 0, 255})},
+	}
+
+	// Relative paths are loaded from the document's directory in fsys, and missing images are left out
+	checkCenterPixel := func(want color.RGBA) {
+		t.Helper()
+
+		tree, err := ParseFromFS(fsys, "icons/logo.svg", opts)
+		if err != nil {
+			t.Fatalf("ParseFromFS failed: %v", err)
+		}
+		defer tree.Close()
+
+		img, err := tree.Render(IdentityTransform(), 4, 4)
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		if got := img.RGBAAt(2, 2); got != want {
+			t.Fatalf("Expected %v, got %v", want, got)
+		}
+	}
+	checkCenterPixel(color.RGBA{0, 255, 0, 255})
+
+	// A Resolver set on the options takes precedence
+	if err := opts.SetResolver(MapResolver{
+		"img/dot.png": encodeTestPNG(t, color.NRGBA{0, 0, 255, 255}),
+		"missing.png": encodeTestPNG(t, color.NRGBA{255, 0, 0, 255}),
+	}); err != nil {
+		t.Fatalf("SetResolver failed: %v", err)
+	}
+	checkCenterPixel(color.RGBA{255, 0, 0, 255})
+}
+
+func TestParseFromFSHostPaths(t *testing.T) {
+	opts := NewOptions()
+	defer opts.Close()
+
+	// An image on the host disk, outside fsys, that must not be loaded however it's referred to
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "red.png"), encodeTestPNG(t, color.NRGBA{255, 0, 0, 255}), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := opts.SetResourcesDir(dir); err != nil {
+		t.Fatalf("SetResourcesDir failed: %v", err)
+	}
+	hostPath := filepath.ToSlash(filepath.Join(dir, "red.png"))
+
+	doc := `<svg width="4" height="4" xmlns="http://www.w3.org/2000/svg">
+		<image href="/etc/passwd" width="4" height="4"/>
+		<image href="file:///etc/passwd" width="4" height="4"/>
+		<image href="` + hostPath + `" width="4" height="4"/>
+		<image href="file://` + hostPath + `" width="4" height="4"/>
+		<image href="red.png" width="4" height="4"/>
+	</svg>`
+	fsys := fstest.MapFS{"logo.svg": {Data: []byte(doc)}}
+
+	dirFS, err := fs.Sub(fsys, ".")
+	if err != nil {
+		t.Fatalf("fs.Sub failed: %v", err)
+	}
+	rewritten, err := resolveRelativeHrefs([]byte(doc), dirFS)
+	if err != nil {
+		t.Fatalf("resolveRelativeHrefs failed: %v", err)
+	}
+	if bytes.Contains(rewritten, []byte("passwd")) || bytes.Contains(rewritten, []byte("red.png")) {
+		t.Fatalf("Expected every href outside fsys to be removed, got %s", rewritten)
+	}
+
+	tree, err := ParseFromFS(fsys, "logo.svg", opts)
+	if err != nil {
+		t.Fatalf("ParseFromFS failed: %v", err)
+	}
+	defer tree.Close()
+
+	img, err := tree.Render(IdentityTransform(), 4, 4)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if got := img.RGBAAt(2, 2); got != (color.RGBA{}) {
+		t.Fatalf("Expected nothing to be loaded from the host, got %v", got)
+	}
+}
+
+func TestIsRelativeHref(t *testing.T) {
+	tests := []struct {
+		href string
+		want bool
+	}{
+		{"a.png", true},
+		{"img/a.png", true},
+		{"../a.png", true},
+		{"/etc/passwd", false},
+		{`\\server\share\a.png`, false},
+		{`C:\a.png`, false},
+		{"https://example.com/a.png", false},
+		{"file:///etc/passwd", false},
+	}
+
+	for _, test := range tests {
+		if got := isRelativeHref(test.href); got != test.want {
+			t.Errorf("isRelativeHref(%q) = %v, expected %v", test.href, got, test.want)
+		}
+	}
+}
+
+func TestParseSVGZ(t *testing.T) {
+	opts := NewOptions()
+	defer opts.Close()
+
+	compressed := gzipData(t, []byte(parseTestSVG))
+	path := filepath.Join(t.TempDir(), "test.svgz")
+	if err := os.WriteFile(path, compressed, 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	tree, err := ParseFromData(compressed, opts)
+	checkParsedSize(t, tree, err)
+
+	tree, err = ParseFromFile(path, opts)
+	checkParsedSize(t, tree, err)
+
+	// Truncated gzip data should fail the same way on every path
+	malformed := compressed[:len(compressed)/2]
+	if err := os.WriteFile(path, malformed, 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	fsys := fstest.MapFS{"test.svgz": {Data: malformed}}
+
+	parsers := map[string]func() (*RenderTree, error){
+		"ParseFromData":   func() (*RenderTree, error) { return ParseFromData(malformed, opts) },
+		"ParseFromFile":   func() (*RenderTree, error) { return ParseFromFile(path, opts) },
+		"ParseFromReader": func() (*RenderTree, error) { return ParseFromReader(bytes.NewReader(malformed), opts) },
+		"ParseFromFS":     func() (*RenderTree, error) { return ParseFromFS(fsys, "test.svgz", opts) },
+	}
+	for name, parse := range parsers {
+		if _, err := parse(); !errors.Is(err, ErrMalformedGzip) {
+			t.Errorf("%s: expected ErrMalformedGzip, got %v", name, err)
+		}
+	}
+}
+
+func TestParseFromFileResourcesDir(t *testing.T) {
+	dir := t.TempDir()
+
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = 0, 0, 255, 255
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "blue.png"), buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	svgPath := filepath.Join(dir, "image.svg")
+	svg := `<svg width="4" height="4" xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink">
+		<image width="4" height="4" xlink:href="blue.png"/>
+	</svg>`
+	if err := os.WriteFile(svgPath, []byte(svg), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	opts := NewOptions()
+	defer opts.Close()
+
+	tree, err := ParseFromFile(svgPath, opts)
+	if err != nil {
+		t.Fatalf("ParseFromFile failed: %v", err)
+	}
+	defer tree.Close()
+
+	rendered, err := tree.Render(IdentityTransform(), 4, 4)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if got := rendered.RGBAAt(2, 2); got != (color.RGBA{0, 0, 255, 255}) {
+		t.Fatalf("Expected image from the file's directory to be drawn, got %v", got)
+	}
+}
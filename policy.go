@@ -0,0 +1,103 @@
+package resvg
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// ResourcePolicy restricts the external resources an untrusted document may load. References to data: URIs
+// and to elements in the same document are always allowed. Absolute paths, paths that go up a directory
+// and file: URIs are always blocked. Anything else, including relative paths and other URIs, is blocked
+// unless it matches the allowlist.
+type ResourcePolicy struct {
+	// Allow lists the hrefs that may be loaded, as patterns in the syntax used by path.Match.
+	// For example, "icons/*.png" or "https://cdn.example.com/*".
+	Allow []string
+
+	// Warn, if set, is called with each blocked reference, which is then removed from the document so
+	// parsing can continue. If nil, a blocked reference fails the parse.
+	// Either way, the error wraps ErrResourceBlocked.
+	Warn func(err error)
+}
+
+// SetResourcePolicy sets the policy that external references must pass before a document is parsed.
+// It's checked before any Resolver is used. If nil, the default, all references are allowed.
+func (o *Options) SetResourcePolicy(p *ResourcePolicy) error {
+	if err := o.acquire(); err != nil {
+		return err
+	}
+	defer o.release()
+	o.settingsMu.Lock()
+	defer o.settingsMu.Unlock()
+
+	if p == nil {
+		o.resourcePolicy = nil
+		return nil
+	}
+	policy := *p
+	policy.Allow = append([]string(nil), p.Allow...)
+	o.resourcePolicy = &policy
+	return nil
+}
+
+// driveLetterPattern matches the start of an absolute Windows path
+var driveLetterPattern = regexp.MustCompile(`^[A-Za-z]:`)
+
+// check returns an error wrapping ErrResourceBlocked if href isn't allowed
+func (p *ResourcePolicy) check(href string) error {
+	ref := strings.TrimSpace(href)
+
+	// resvg treats hrefs as plain paths, but check the percent-decoded form too, in case something
+	// downstream decodes it
+	forms := []string{ref}
+	if decoded, err := url.PathUnescape(ref); err == nil && decoded != ref {
+		forms = append(forms, decoded)
+	}
+	for _, form := range forms {
+		switch {
+		case strings.HasPrefix(strings.ToLower(form), "file:"):
+			return fmt.Errorf("%w: file URI", ErrResourceBlocked)
+		case strings.HasPrefix(form, "/") || strings.HasPrefix(form, `\`) || driveLetterPattern.MatchString(form):
+			return fmt.Errorf("%w: absolute path", ErrResourceBlocked)
+		case hasParentDir(form):
+			return fmt.Errorf("%w: parent directory reference", ErrResourceBlocked)
+		}
+	}
+
+	for _, pattern := range p.Allow {
+		if ok, _ := path.Match(pattern, ref); ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: not in allowlist", ErrResourceBlocked)
+}
+
+// hasParentDir reports whether any element of the slash- or backslash-separated path is ".."
+func hasParentDir(p string) bool {
+	for _, elem := range strings.FieldsFunc(p, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if elem == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// apply checks the external references in data against the policy, removing the blocked ones if p.Warn is set
+func (p *ResourcePolicy) apply(data []byte) ([]byte, error) {
+	return rewriteHrefs(data, func(href string) (string, error) {
+		err := p.check(href)
+		if err == nil {
+			return href, nil
+		}
+
+		err = &Error{Op: "resolve", Path: href, Err: err}
+		if p.Warn == nil {
+			return "", err
+		}
+		p.Warn(err)
+		return "", nil
+	})
+}
@@ -0,0 +1,179 @@
+package resvg
+
+import (
+	"errors"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResourcePolicyCheck(t *testing.T) {
+	policy := &ResourcePolicy{Allow: []string{"icons/*.png", "logo.svg", "https://cdn.example.com/*"}}
+
+	tests := []struct {
+		href    string
+		allowed bool
+	}{
+		{"icons/a.png", true},
+		{"logo.svg", true},
+		{"https://cdn.example.com/a.png", true},
+
+		{"icons/a.jpg", false},
+		{"icons/sub/a.png", false},
+		{"other.svg", false},
+		{"https://evil.example.com/a.png", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+
+		{"/etc/passwd", false},
+		{`\etc\passwd`, false},
+		{"C:/Windows/win.ini", false},
+		{`c:\Windows\win.ini`, false},
+		{"//server/share/a.png", false},
+		{"file:///etc/passwd", false},
+		{"FILE:/etc/passwd", false},
+		{"%2Fetc%2Fpasswd", false},
+
+		{"../secret.png", false},
+		{"icons/../../secret.png", false},
+		{"icons/..", false},
+		{`icons\..\..\secret.png`, false},
+		{"icons/%2e%2e/%2e%2e/secret.png", false},
+		{"icons%2F..%2F..%2Fsecret.png", false},
+	}
+
+	for _, test := range tests {
+		err := policy.check(test.href)
+		if test.allowed && err != nil {
+			t.Errorf("check(%q) blocked: %v", test.href, err)
+		}
+		if !test.allowed && !errors.Is(err, ErrResourceBlocked) {
+			t.Errorf("check(%q): expected ErrResourceBlocked, got %v", test.href, err)
+		}
+	}
+
+	// Even an allowlist entry can't let traversal through
+	if err := (&ResourcePolicy{Allow: []string{"*"}}).check(".."); !errors.Is(err, ErrResourceBlocked) {
+		t.Errorf("Expected traversal to be blocked by a catch-all allowlist, got %v", err)
+	}
+}
+
+func TestResourcePolicyApply(t *testing.T) {
+	doc := []byte(`<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink">
+		<image href="icons/a.png"/>
+		<image xlink:href="../../etc/passwd"/>
+		<image href="data:image/png;base64,AAAA"/>
+	</svg>`)
+
+	_, err := (&ResourcePolicy{Allow: []string{"icons/*"}}).apply(doc)
+	if !errors.Is(err, ErrResourceBlocked) {
+		t.Fatalf("Expected ErrResourceBlocked, got %v", err)
+	}
+	var resvgErr *Error
+	if !errors.As(err, &resvgErr) || resvgErr.Path != "../../etc/passwd" {
+		t.Fatalf("Expected *Error with href, got %v", err)
+	}
+
+	var warnings []error
+	policy := &ResourcePolicy{
+		Allow: []string{"icons/*"},
+		Warn:  func(err error) { warnings = append(warnings, err) },
+	}
+	out, err := policy.apply(doc)
+	if err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	if len(warnings) != 1 || !errors.Is(warnings[0], ErrResourceBlocked) {
+		t.Fatalf("Expected one ErrResourceBlocked warning, got %v", warnings)
+	}
+	for _, want := range []string{
+		`<image href="icons/a.png"/>`,
+		`<image xlink:href=""/>`,
+		`<image href="data:image/png;base64,AAAA"/>`,
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("Expected output to contain %s", want)
+		}
+	}
+
+	// References that a pattern match on the markup would miss must still be checked
+	for _, doc := range []string{
+		`<svg xmlns="http://www.w3.org/2000/svg" xmlns:l="http://www.w3.org/1999/xlink"><image l:href="/etc/passwd"/></svg>`,
+		`<svg xmlns="http://www.w3.org/2000/svg"><image x=">" href="/etc/passwd"/></svg>`,
+		`<svg xmlns="http://www.w3.org/2000/svg"><image x='a>b' y="'>" href="/etc/passwd"/></svg>`,
+		`<!DOCTYPE svg [<!ENTITY p "/etc/passwd">]><svg xmlns="http://www.w3.org/2000/svg"><image href="&p;"/></svg>`,
+		`<svg xmlns="http://www.w3.org/2000/svg"><image href="&#47;etc&#x2F;passwd"/></svg>`,
+		`<!DOCTYPE svg [<!ENTITY i "<image href='/etc/passwd'/>">]><svg xmlns="http://www.w3.org/2000/svg">&i;</svg>`,
+		`<!DOCTYPE svg [<!ENTITY i "&#60;image href='/etc/passwd'/>">]><svg xmlns="http://www.w3.org/2000/svg">&i;</svg>`,
+	} {
+		_, err := (&ResourcePolicy{Allow: []string{"*"}}).apply([]byte(doc))
+		if !errors.Is(err, ErrResourceBlocked) {
+			t.Errorf("%s: expected ErrResourceBlocked, got %v", doc, err)
+		}
+		if !errors.As(err, &resvgErr) || resvgErr.Path != "/etc/passwd" {
+			t.Errorf("%s: expected *Error with the expanded href, got %v", doc, err)
+		}
+	}
+
+	// With Warn set, a blocked reference in an entity is removed by expanding the entity
+	policy.Warn = func(error) {}
+	out, err = policy.apply([]byte(`<!DOCTYPE svg [<!ENTITY i "<image href='/etc/passwd'/>">]><svg>&i;</svg>`))
+	if err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	if want := `<svg><image href=''/></svg>`; !strings.Contains(string(out), want) {
+		t.Errorf("Expected output to contain %s, got %s", want, out)
+	}
+}
+
+func TestSetResourcePolicy(t *testing.T) {
+	root := t.TempDir()
+	docs := filepath.Join(root, "docs")
+	if err := os.Mkdir(docs, 0o755); err != nil {
+		t.Fatalf("Mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "secret.png"), encodeTestPNG(t, color.NRGBA{255, 0, 0, 255}), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	svgPath := filepath.Join(docs, "upload.svg")
+	svg := `<svg width="4" height="4" xmlns="http://www.w3.org/2000/svg">
+		<image width="4" height="4" href="../secret.png"/>
+	</svg>`
+	if err := os.WriteFile(svgPath, []byte(svg), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	opts := NewOptions()
+	defer opts.Close()
+	if err := opts.SetResourcePolicy(&ResourcePolicy{}); err != nil {
+		t.Fatalf("SetResourcePolicy failed: %v", err)
+	}
+
+	_, err := ParseFromFile(svgPath, opts)
+	if !errors.Is(err, ErrResourceBlocked) {
+		t.Fatalf("Expected ErrResourceBlocked, got %v", err)
+	}
+
+	var warned error
+	if err := opts.SetResourcePolicy(&ResourcePolicy{Warn: func(err error) { warned = err }}); err != nil {
+		t.Fatalf("SetResourcePolicy failed: %v", err)
+	}
+	tree, err := ParseFromFile(svgPath, opts)
+	if err != nil {
+		t.Fatalf("ParseFromFile failed: %v", err)
+	}
+	defer tree.Close()
+	if !errors.Is(warned, ErrResourceBlocked) {
+		t.Fatalf("Expected ErrResourceBlocked warning, got %v", warned)
+	}
+
+	img, err := tree.Render(IdentityTransform(), 4, 4)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if got := img.RGBAAt(2, 2); got.A != 0 {
+		t.Fatalf("Expected blocked image not to be drawn, got %v", got)
+	}
+}
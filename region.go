@@ -0,0 +1,90 @@
+package resvg
+
+import (
+	"fmt"
+	"image"
+)
+
+// RenderRegion renders the part of the SVG tree within region, in user units, to a width x height RGBA image.
+// If the aspect ratio of region doesn't match the image, the region is scaled and aligned according to fit.
+func (t *RenderTree) RenderRegion(region Rect, width, height uint32, fit FitOptions) (*image.RGBA, error) {
+	if region.Empty() {
+		return nil, &Error{Op: "render", Err: fmt.Errorf("%w: region %vx%v", ErrInvalidSize, region.Width, region.Height)}
+	}
+
+	transform := fitTransform(region, width, height, fit.Mode, fit.Align)
+	img, err := t.Render(transform, width, height)
+	if err != nil {
+		return nil, err
+	}
+
+	if fit.Background != nil {
+		fillBackground(img, fit.Background)
+	}
+	return img, nil
+}
+
+// Viewport tracks the pan and zoom state of a view onto a document, such as in an interactive viewer.
+// The visible part of the document is Region, which is fitted to a Width x Height output according to Fit.
+type Viewport struct {
+	// Region is the visible rectangle of the document, in user units
+	Region Rect
+
+	// Width and Height are the size of the output, in pixels
+	Width, Height uint32
+
+	// Fit controls how Region is fitted to the output when their aspect ratios differ. Zooming relies on
+	// Region being scaled to the output, so it has no useful effect with FitNone or FitScaleDown.
+	Fit FitOptions
+}
+
+// NewViewport creates a Viewport showing region in a width x height output, scaled to fit and centered
+func NewViewport(region Rect, width, height uint32) *Viewport {
+	return &Viewport{Region: region, Width: width, Height: height}
+}
+
+// Transform returns the transform from user units to output pixels
+func (v *Viewport) Transform() Transform {
+	return fitTransform(v.Region, v.Width, v.Height, v.Fit.Mode, v.Fit.Align)
+}
+
+// Pan moves the content by dx, dy output pixels, as when it's dragged
+func (v *Viewport) Pan(dx, dy float32) {
+	transform := v.Transform()
+	if transform.A != 0 {
+		v.Region.X -= dx / transform.A
+	}
+	if transform.D != 0 {
+		v.Region.Y -= dy / transform.D
+	}
+}
+
+// Zoom scales the content by factor around the center of the output. A factor above 1 zooms in.
+func (v *Viewport) Zoom(factor float64) {
+	v.ZoomAt(factor, float32(v.Width)/2, float32(v.Height)/2)
+}
+
+// ZoomAt scales the content by factor around the output pixel (x, y), such as the position of the mouse,
+// so that the point of the document under it stays in place. A factor above 1 zooms in.
+func (v *Viewport) ZoomAt(factor float64, x, y float32) {
+	if !(factor > 0) {
+		return
+	}
+	inverse, ok := v.Transform().Invert()
+	if !ok {
+		return
+	}
+
+	px, py := inverse.ApplyPoint(x, y)
+	v.Region = Rect{
+		X:      px - float32(float64(px-v.Region.X)/factor),
+		Y:      py - float32(float64(py-v.Region.Y)/factor),
+		Width:  float32(float64(v.Region.Width) / factor),
+		Height: float32(float64(v.Region.Height) / factor),
+	}
+}
+
+// Render renders the visible part of tree
+func (v *Viewport) Render(tree *RenderTree) (*image.RGBA, error) {
+	return tree.RenderRegion(v.Region, v.Width, v.Height, v.Fit)
+}
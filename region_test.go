@@ -0,0 +1,148 @@
+package resvg
+
+import (
+	"errors"
+	"image/color"
+	"testing"
+)
+
+// regionTestSVG is red on its left half and blue on its right half
+const regionTestSVG = `<svg width="200" height="100" xmlns="http://www.w3.org/2000/svg">
+	<rect width="100" height="100" fill="red"/>
+	<rect x="100" width="100" height="100" fill="blue"/>
+</svg>`
+
+func TestRenderRegion(t *testing.T) {
+	opts := NewOptions()
+	defer opts.Close()
+
+	tree, err := ParseFromData([]byte(regionTestSVG), opts)
+	if err != nil {
+		t.Fatalf("ParseFromData failed: %v", err)
+	}
+	defer tree.Close()
+
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+
+	// Zoomed into the right half
+	img, err := tree.RenderRegion(Rect{X: 100, Width: 100, Height: 100}, 50, 50, FitOptions{})
+	if err != nil {
+		t.Fatalf("RenderRegion failed: %v", err)
+	}
+	for _, p := range [][2]int{{0, 0}, {25, 25}, {49, 49}} {
+		if got := img.RGBAAt(p[0], p[1]); got != blue {
+			t.Fatalf("Expected blue at %v, got %v", p, got)
+		}
+	}
+
+	// Straddling both halves
+	img, err = tree.RenderRegion(Rect{X: 90, Y: 40, Width: 20, Height: 20}, 40, 40, FitOptions{})
+	if err != nil {
+		t.Fatalf("RenderRegion failed: %v", err)
+	}
+	if got := img.RGBAAt(5, 20); got != red {
+		t.Fatalf("Expected red on the left, got %v", got)
+	}
+	if got := img.RGBAAt(35, 20); got != blue {
+		t.Fatalf("Expected blue on the right, got %v", got)
+	}
+
+	// A square region in a wide output is letterboxed when contained, and stretched when filled
+	square := Rect{X: 100, Width: 100, Height: 100}
+	img, err = tree.RenderRegion(square, 100, 50, FitOptions{Mode: FitContain})
+	if err != nil {
+		t.Fatalf("RenderRegion failed: %v", err)
+	}
+	if got := img.RGBAAt(10, 25); got.A != 0 {
+		t.Fatalf("Expected transparent letterbox, got %v", got)
+	}
+	if got := img.RGBAAt(50, 25); got != blue {
+		t.Fatalf("Expected blue in the middle, got %v", got)
+	}
+
+	img, err = tree.RenderRegion(square, 100, 50, FitOptions{Mode: FitFill})
+	if err != nil {
+		t.Fatalf("RenderRegion failed: %v", err)
+	}
+	if got := img.RGBAAt(10, 25); got != blue {
+		t.Fatalf("Expected stretched content, got %v", got)
+	}
+
+	if _, err := tree.RenderRegion(Rect{Width: 0, Height: 10}, 10, 10, FitOptions{}); !errors.Is(err, ErrInvalidSize) {
+		t.Fatalf("Expected ErrInvalidSize for an empty region, got %v", err)
+	}
+}
+
+func TestViewport(t *testing.T) {
+	v := NewViewport(Rect{Width: 200, Height: 100}, 400, 200)
+	if got, expected := v.Transform(), (Transform{A: 2, D: 2}); got != expected {
+		t.Fatalf("Expected %+v, got %+v", expected, got)
+	}
+
+	// Dragging the content 20px right shows 10 units further left
+	v.Pan(20, -10)
+	if expected := (Rect{X: -10, Y: 5, Width: 200, Height: 100}); v.Region != expected {
+		t.Fatalf("Expected region %+v after Pan, got %+v", expected, v.Region)
+	}
+
+	// Zooming around a point keeps the document point under it in place
+	before := v.Transform()
+	invBefore, _ := before.Invert()
+	docX, docY := invBefore.ApplyPoint(100, 50)
+
+	v.ZoomAt(4, 100, 50)
+	x, y := v.Transform().ApplyPoint(docX, docY)
+	if !floatsEqual(x, 100) || !floatsEqual(y, 50) {
+		t.Fatalf("Expected document point to stay at (100, 50), moved to (%v, %v)", x, y)
+	}
+	if !floatsEqual(v.Region.Width, 50) || !floatsEqual(v.Region.Height, 25) {
+		t.Fatalf("Expected 50x25 region after zooming 4x, got %+v", v.Region)
+	}
+
+	// Zooming out around the center restores the size
+	v.Zoom(0.25)
+	if !floatsEqual(v.Region.Width, 200) || !floatsEqual(v.Region.Height, 100) {
+		t.Fatalf("Expected 200x100 region after zooming out, got %+v", v.Region)
+	}
+
+	// Invalid factors are ignored
+	region := v.Region
+	v.Zoom(0)
+	v.Zoom(-1)
+	if v.Region != region {
+		t.Fatalf("Expected invalid zoom to be ignored, got %+v", v.Region)
+	}
+
+	// A letterboxed viewport pans at the uniform scale
+	v = NewViewport(Rect{Width: 100, Height: 100}, 400, 200)
+	v.Pan(20, 20)
+	if expected := (Rect{X: -10, Y: -10, Width: 100, Height: 100}); v.Region != expected {
+		t.Fatalf("Expected region %+v after Pan, got %+v", expected, v.Region)
+	}
+}
+
+func TestViewportRender(t *testing.T) {
+	opts := NewOptions()
+	defer opts.Close()
+
+	tree, err := ParseFromData([]byte(regionTestSVG), opts)
+	if err != nil {
+		t.Fatalf("ParseFromData failed: %v", err)
+	}
+	defer tree.Close()
+
+	v := NewViewport(Rect{Width: 200, Height: 100}, 200, 100)
+	v.ZoomAt(2, 150, 50)
+
+	img, err := v.Render(tree)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if img.Bounds().Dx() != 200 || img.Bounds().Dy() != 100 {
+		t.Fatalf("Expected 200x100 image, got %v", img.Bounds())
+	}
+	if got := img.RGBAAt(180, 50); got != (color.RGBA{0, 0, 255, 255}) {
+		t.Fatalf("Expected blue after zooming into the right half, got %v", got)
+	}
+}
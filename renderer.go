@@ -0,0 +1,211 @@
+package resvg
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"sync"
+)
+
+// Renderer renders SVG documents using a fixed set of Options
+type Renderer struct {
+	opts *Options
+}
+
+// The default options are shared by renderers created without options, including the one used by the
+// convenience render functions, so that system fonts are only loaded once. defaultOptionsMu is held for
+// reading while the options are in use.
+var (
+	defaultOptionsMu    sync.RWMutex
+	defaultOptions      *Options
+	defaultOptionsOwned bool
+)
+
+// defaultRenderer is used by the convenience render functions
+var defaultRenderer = NewRenderer(nil)
+
+// NewRenderer creates a Renderer that parses documents with opts. The caller keeps ownership of opts
+// and must not close it while the renderer is in use. If opts is nil, the renderer uses the shared
+// default options, which have system fonts loaded.
+func NewRenderer(opts *Options) *Renderer {
+	return &Renderer{opts: opts}
+}
+
+// Render renders SVG data to an RGBA image at its natural size
+func (r *Renderer) Render(data []byte) (*image.RGBA, error) {
+	tree, err := r.parseData(data)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+
+	return renderNaturalSize(tree, "")
+}
+
+// RenderFile renders an SVG file to an RGBA image at its natural size
+func (r *Renderer) RenderFile(path string) (*image.RGBA, error) {
+	tree, err := r.parseFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+
+	return renderNaturalSize(tree, path)
+}
+
+// RenderWithSize renders SVG data to an RGBA image with specified dimensions
+func (r *Renderer) RenderWithSize(data []byte, width, height uint32) (*image.RGBA, error) {
+	tree, err := r.parseData(data)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+
+	return tree.Render(IdentityTransform(), width, height)
+}
+
+// RenderScaledToSize renders SVG data to an RGBA image, scaling the content to fit the specified dimensions
+// while preserving aspect ratio and centering it on the canvas. If the natural aspect ratio doesn't match
+// the target, the content will be letterboxed, leaving the rest of the canvas transparent.
+func (r *Renderer) RenderScaledToSize(data []byte, width, height uint32) (*image.RGBA, error) {
+	return r.RenderFit(data, width, height, FitOptions{Mode: FitContain, Align: AlignXMidYMid})
+}
+
+// RenderFit renders SVG data to an RGBA image with the specified dimensions, scaling and aligning the
+// content according to fit
+func (r *Renderer) RenderFit(data []byte, width, height uint32, fit FitOptions) (*image.RGBA, error) {
+	tree, err := r.parseData(data)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+
+	return tree.RenderFit(width, height, fit)
+}
+
+// parseData parses SVG data with the renderer's options, failing if it has nothing to render
+func (r *Renderer) parseData(data []byte) (*RenderTree, error) {
+	opts, release, err := r.acquireOptions()
+	if err != nil {
+		return nil, err
+	}
+	tree, err := ParseFromData(data, opts)
+	release()
+	if err != nil {
+		return nil, err
+	}
+
+	return checkRenderable(tree, "")
+}
+
+// parseFile parses an SVG file with the renderer's options, failing if it has nothing to render
+func (r *Renderer) parseFile(path string) (*RenderTree, error) {
+	opts, release, err := r.acquireOptions()
+	if err != nil {
+		return nil, err
+	}
+	tree, err := ParseFromFile(path, opts)
+	release()
+	if err != nil {
+		return nil, err
+	}
+
+	return checkRenderable(tree, path)
+}
+
+// acquireOptions returns the options to parse with. The caller must call release once it's done using them.
+func (r *Renderer) acquireOptions() (opts *Options, release func(), err error) {
+	if r.opts != nil {
+		return r.opts, func() {}, nil
+	}
+	return acquireDefaultOptions()
+}
+
+// SetDefaultOptions replaces the options used by the convenience render functions and renderers created
+// without options. The caller keeps ownership of opts and must not close it while it is still set as the
+// default. Passing nil restores the lazily created default options, which have system fonts loaded.
+func SetDefaultOptions(opts *Options) {
+	defaultOptionsMu.Lock()
+	defer defaultOptionsMu.Unlock()
+
+	if defaultOptionsOwned {
+		defaultOptions.Close()
+	}
+	defaultOptions = opts
+	defaultOptionsOwned = false
+}
+
+// ResetDefaultOptions discards the options used by the convenience render functions. They are created
+// again, with system fonts reloaded, on the next call. This is useful after fonts are installed or removed.
+func ResetDefaultOptions() {
+	SetDefaultOptions(nil)
+}
+
+// acquireDefaultOptions returns the default options, creating them if needed.
+// The caller must call release once it's done using them.
+func acquireDefaultOptions() (opts *Options, release func(), err error) {
+	for {
+		defaultOptionsMu.RLock()
+		if defaultOptions != nil {
+			return defaultOptions, defaultOptionsMu.RUnlock, nil
+		}
+		defaultOptionsMu.RUnlock()
+
+		defaultOptionsMu.Lock()
+		if defaultOptions == nil {
+			created := NewOptions()
+			if err := created.LoadSystemFonts(); err != nil {
+				defaultOptionsMu.Unlock()
+				return nil, nil, err
+			}
+			defaultOptions = created
+			defaultOptionsOwned = true
+		}
+		defaultOptionsMu.Unlock()
+	}
+}
+
+// checkRenderable returns tree, parsed from the file path if it isn't empty, if it has something to render,
+// and closes it otherwise
+func checkRenderable(tree *RenderTree, path string) (*RenderTree, error) {
+	empty, err := tree.IsEmpty()
+	if err != nil {
+		tree.Close()
+		return nil, withPath(err, path)
+	}
+	if empty {
+		tree.Close()
+		return nil, &Error{Op: "render", Path: path, Err: ErrEmptyImage}
+	}
+	return tree, nil
+}
+
+// renderNaturalSize renders tree, parsed from the file path if it isn't empty, at the natural size of the SVG
+func renderNaturalSize(tree *RenderTree, path string) (*image.RGBA, error) {
+	size, err := tree.GetImageSize()
+	if err != nil {
+		return nil, withPath(err, path)
+	}
+	if size.Width <= 0 || size.Height <= 0 {
+		return nil, &Error{Op: "render", Path: path, Err: ErrInvalidDimensions}
+	}
+	if size.Width > math.MaxUint32 || size.Height > math.MaxUint32 {
+		return nil, &Error{Op: "render", Path: path, Err: fmt.Errorf("%w: %.0fx%.0f", ErrInvalidDimensions, size.Width, size.Height)}
+	}
+
+	img, err := tree.Render(IdentityTransform(), uint32(size.Width), uint32(size.Height))
+	if err != nil {
+		return nil, withPath(err, path)
+	}
+	return img, nil
+}
+
+// withPath fills in path on err if it's an *Error that doesn't say which file it's about
+func withPath(err error, path string) error {
+	var resvgErr *Error
+	if path != "" && errors.As(err, &resvgErr) && resvgErr.Path == "" {
+		resvgErr.Path = path
+	}
+	return err
+}
@@ -0,0 +1,144 @@
+package resvg
+
+import (
+	"errors"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenderer(t *testing.T) {
+	svgData := []byte(`<svg width="10" height="20" xmlns="http://www.w3.org/2000/svg">
+		<rect width="10" height="20" fill="red"/>
+	</svg>`)
+
+	opts := NewOptions()
+	defer opts.Close()
+	opts.SetStylesheet("rect { fill: lime; }")
+
+	renderer := NewRenderer(opts)
+	lime := color.RGBA{0, 255, 0, 255}
+
+	img, err := renderer.Render(svgData)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != 10 || bounds.Dy() != 20 {
+		t.Fatalf("Expected 10x20 image, got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+	if got := img.RGBAAt(5, 5); got != lime {
+		t.Fatalf("Stylesheet was not applied: expected %v, got %v", lime, got)
+	}
+
+	img, err = renderer.RenderWithSize(svgData, 30, 40)
+	if err != nil {
+		t.Fatalf("RenderWithSize failed: %v", err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != 30 || bounds.Dy() != 40 {
+		t.Fatalf("Expected 30x40 image, got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+
+	img, err = renderer.RenderScaledToSize(svgData, 40, 40)
+	if err != nil {
+		t.Fatalf("RenderScaledToSize failed: %v", err)
+	}
+	if got := img.RGBAAt(20, 20); got != lime {
+		t.Fatalf("Center pixel incorrect: expected %v, got %v", lime, got)
+	}
+	if got := img.RGBAAt(5, 20); got != (color.RGBA{}) {
+		t.Fatalf("Letterbox pixel should be transparent, got %v", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "test.svg")
+	if err := os.WriteFile(path, svgData, 0644); err != nil {
+		t.Fatalf("Failed to write SVG file: %v", err)
+	}
+
+	img, err = renderer.RenderFile(path)
+	if err != nil {
+		t.Fatalf("RenderFile failed: %v", err)
+	}
+	if got := img.RGBAAt(5, 5); got != lime {
+		t.Fatalf("Stylesheet was not applied: expected %v, got %v", lime, got)
+	}
+
+	if _, err := renderer.RenderFile(filepath.Join(t.TempDir(), "missing.svg")); !errors.Is(err, ErrFileOpenFailed) {
+		t.Fatalf("Expected ErrFileOpenFailed for missing file, got %v", err)
+	}
+
+	// Errors after parsing say which file they're about too
+	emptyPath := filepath.Join(t.TempDir(), "empty.svg")
+	if err := os.WriteFile(emptyPath, []byte(`<svg width="10" height="10" xmlns="http://www.w3.org/2000/svg"/>`), 0644); err != nil {
+		t.Fatalf("Failed to write SVG file: %v", err)
+	}
+	_, err = renderer.RenderFile(emptyPath)
+	var resvgErr *Error
+	if !errors.Is(err, ErrEmptyImage) || !errors.As(err, &resvgErr) || resvgErr.Path != emptyPath {
+		t.Fatalf("Expected ErrEmptyImage for %s, got %v", emptyPath, err)
+	}
+}
+
+func TestDefaultOptions(t *testing.T) {
+	svgData := []byte(`<svg width="10" height="10" xmlns="http://www.w3.org/2000/svg">
+		<rect width="10" height="10" fill="red"/>
+	</svg>`)
+
+	// Custom default options should be picked up by the convenience functions
+	opts := NewOptions()
+	defer opts.Close()
+	opts.SetMaxPixels(1)
+
+	SetDefaultOptions(opts)
+	if _, err := RenderWithSize(svgData, 10, 10); !errors.Is(err, ErrTooManyPixels) {
+		t.Fatalf("Expected ErrTooManyPixels with custom default options, got %v", err)
+	}
+
+	SetDefaultOptions(nil)
+	if _, err := RenderWithSize(svgData, 10, 10); err != nil {
+		t.Fatalf("RenderWithSize with restored default options failed: %v", err)
+	}
+
+	ResetDefaultOptions()
+	if _, err := Render(svgData); err != nil {
+		t.Fatalf("Render after reset failed: %v", err)
+	}
+}
+
+var benchmarkSVG = []byte(`<svg width="64" height="64" xmlns="http://www.w3.org/2000/svg">
+	<circle cx="32" cy="32" r="24" fill="red"/>
+	<text x="8" y="40" font-size="12">resvg</text>
+</svg>`)
+
+func BenchmarkRender(b *testing.B) {
+	// Make sure the default options are built before timing
+	if _, err := Render(benchmarkSVG); err != nil {
+		b.Fatalf("Render failed: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Render(benchmarkSVG); err != nil {
+			b.Fatalf("Render failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkRenderFreshOptions(b *testing.B) {
+	// This is what the convenience functions did before the default options were shared
+	for i := 0; i < b.N; i++ {
+		opts := NewOptions()
+		opts.LoadSystemFonts()
+
+		tree, err := ParseFromData(benchmarkSVG, opts)
+		if err != nil {
+			b.Fatalf("ParseFromData failed: %v", err)
+		}
+		if _, err := tree.Render(IdentityTransform(), 64, 64); err != nil {
+			b.Fatalf("Render failed: %v", err)
+		}
+
+		tree.Close()
+		opts.Close()
+	}
+}
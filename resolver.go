@@ -0,0 +1,254 @@
+package resvg
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Resolver loads the external resources, such as images, that a document refers to
+type Resolver interface {
+	// Resolve returns the contents of the resource at href. It should return an error wrapping
+	// ErrResourceNotFound if there's no such resource.
+	Resolve(href string) ([]byte, error)
+}
+
+// ContextResolver is a Resolver that can give up when a context is done. ParseContext passes its context
+// to resolvers that implement it, so that a slow resource doesn't keep an abandoned parse running.
+type ContextResolver interface {
+	Resolver
+
+	// ResolveContext returns the contents of the resource at href like Resolve, giving up when ctx is done
+	ResolveContext(ctx context.Context, href string) ([]byte, error)
+}
+
+// ResolverFunc is an adapter to allow the use of an ordinary function as a Resolver
+type ResolverFunc func(href string) ([]byte, error)
+
+// Resolve calls f(href)
+func (f ResolverFunc) Resolve(href string) ([]byte, error) {
+	return f(href)
+}
+
+// FSResolver resolves hrefs as slash-separated paths relative to the root of FS
+type FSResolver struct {
+	FS fs.FS
+}
+
+// Resolve reads the file named by href from r.FS
+func (r FSResolver) Resolve(href string) ([]byte, error) {
+	name := path.Clean(strings.TrimPrefix(href, "/"))
+	if !fs.ValidPath(name) {
+		return nil, fmt.Errorf("%w: invalid path %q", ErrResourceNotFound, href)
+	}
+	data, err := fs.ReadFile(r.FS, name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrResourceNotFound, err)
+	}
+	return data, nil
+}
+
+// MapResolver resolves hrefs by looking them up in the map
+type MapResolver map[string][]byte
+
+// Resolve returns the entry for href
+func (m MapResolver) Resolve(href string) ([]byte, error) {
+	data, ok := m[href]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrResourceNotFound, href)
+	}
+	return data, nil
+}
+
+// defaultHTTPClient is used by HTTPResolver when it has no Client, so that a server that never responds
+// can't stall parsing forever
+var defaultHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// HTTPResolver resolves hrefs by fetching them over HTTP or HTTPS
+type HTTPResolver struct {
+	// Client is used to make requests. If nil, a client with a 30 second timeout is used.
+	Client *http.Client
+
+	// BaseURL is the URL relative hrefs are resolved against. If empty, only absolute URLs are fetched.
+	BaseURL string
+
+	// MaxBytes is the largest response body that will be accepted. Larger responses fail with an error
+	// wrapping ErrInputTooLarge. If 0, there is no limit.
+	MaxBytes int64
+}
+
+// Resolve fetches href with a GET request
+func (r HTTPResolver) Resolve(href string) ([]byte, error) {
+	return r.ResolveContext(context.Background(), href)
+}
+
+// ResolveContext fetches href with a GET request, giving up when ctx is done
+func (r HTTPResolver) ResolveContext(ctx context.Context, href string) ([]byte, error) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return nil, err
+	}
+	if r.BaseURL != "" {
+		base, err := url.Parse(r.BaseURL)
+		if err != nil {
+			return nil, err
+		}
+		u = base.ResolveReference(u)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("%w: unsupported URL %q", ErrResourceNotFound, u)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s returned %s", ErrResourceNotFound, u, resp.Status)
+	}
+
+	data, err := readLimited(resp.Body, r.MaxBytes)
+	if errors.Is(err, ErrInputTooLarge) {
+		return nil, fmt.Errorf("%s: %w", u, err)
+	} else if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// client returns the client used to make requests
+func (r HTTPResolver) client() *http.Client {
+	if r.Client != nil {
+		return r.Client
+	}
+	return defaultHTTPClient
+}
+
+// SetResolver sets the Resolver used to load external images. Before a document is parsed, each external
+// href on its image elements is loaded with the resolver and replaced with an inline data: URI. If nil, the
+// default, external images are loaded by resvg from the resources directory.
+func (o *Options) SetResolver(r Resolver) error {
+	if err := o.acquire(); err != nil {
+		return err
+	}
+	defer o.release()
+	o.settingsMu.Lock()
+	defer o.settingsMu.Unlock()
+
+	o.resolver = r
+	return nil
+}
+
+// resolveHrefs rewrites the external hrefs of the image elements in data into data: URIs, using r to load them.
+// If r is a ContextResolver, it's passed ctx.
+func resolveHrefs(ctx context.Context, data []byte, r Resolver) ([]byte, error) {
+	resolved := map[string]string{}
+	return rewriteHrefs(data, func(href string) (string, error) {
+		if uri, ok := resolved[href]; ok {
+			return uri, nil
+		}
+		var (
+			content []byte
+			err     error
+		)
+		if cr, ok := r.(ContextResolver); ok {
+			content, err = cr.ResolveContext(ctx, href)
+		} else {
+			content, err = r.Resolve(href)
+		}
+		if err != nil {
+			return "", &Error{Op: "resolve", Path: href, Err: err}
+		}
+		uri := dataURI(content)
+		resolved[href] = uri
+		return uri, nil
+	})
+}
+
+// isExternalHref reports whether href refers to something outside the document
+func isExternalHref(href string) bool {
+	href = strings.TrimSpace(href)
+	return href != "" && !strings.HasPrefix(href, "#") && !strings.HasPrefix(strings.ToLower(href), "data:")
+}
+
+// isDataHref reports whether href is a data: URI
+func isDataHref(href string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(href)), "data:")
+}
+
+// dataURI encodes content as a base64 data: URI, with a media type resvg recognizes
+func dataURI(content []byte) string {
+	mediaType := http.DetectContentType(content)
+	switch mediaType {
+	case "image/png", "image/jpeg", "image/gif", "image/webp":
+	default:
+		// resvg supports SVG as the only other image format
+		mediaType = "image/svg+xml"
+	}
+	return "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(content)
+}
+
+// decodeDataURI returns the media type and content of the data: URI href, decoded as leniently as resvg
+// decodes it. ok is false if href isn't a data: URI or its content can't be decoded.
+func decodeDataURI(href string) (mediaType string, content []byte, ok bool) {
+	href = strings.TrimSpace(href)
+	comma := strings.IndexByte(href, ',')
+	if !isDataHref(href) || comma < 0 {
+		return "", nil, false
+	}
+
+	params := strings.Split(href[len("data:"):comma], ";")
+	mediaType = strings.ToLower(strings.TrimSpace(params[0]))
+	if mediaType == "" {
+		mediaType = "text/plain"
+	}
+	content = unescapePercent(href[comma+1:])
+	if len(params) == 1 || !strings.EqualFold(strings.TrimSpace(params[len(params)-1]), "base64") {
+		return mediaType, content, true
+	}
+
+	// Whitespace and padding are optional in base64 data
+	encoded := strings.TrimRight(strings.Map(func(r rune) rune {
+		if r < utf8.RuneSelf && isSpace(byte(r)) {
+			return -1
+		}
+		return r
+	}, string(content)), "=")
+	content, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", nil, false
+	}
+	return mediaType, content, true
+}
+
+// unescapePercent replaces the %XX escapes in s with the bytes they stand for, leaving malformed ones as
+// they are
+func unescapePercent(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			if b, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				out = append(out, byte(b))
+				i += 2
+				continue
+			}
+		}
+		out = append(out, s[i])
+	}
+	return out
+}
@@ -0,0 +1,220 @@
+package resvg
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func encodeTestPNG(t *testing.T, c color.NRGBA) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestResolveHrefs(t *testing.T) {
+	pngData := encodeTestPNG(t, color.NRGBA{0, 255, 0, 255})
+	svgData := []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`)
+	resolver := MapResolver{
+		"a.png":         pngData,
+		"b.svg":         svgData,
+		"c.png?x=1&y=2": pngData,
+	}
+
+	doc := []byte(`<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink">
+		<image xlink:href="a.png"/>
+		<image href='b.svg'/>
+		<image href="c.png?x=1&amp;y=2"/>
+		<image href="data:image/png;base64,AAAA"/>
+		<use href="#local"/>
+		<a href="https://example.com/"><rect width="1" height="1"/></a>
+	</svg>`)
+
+	out, err := resolveHrefs(context.Background(), doc, resolver)
+	if err != nil {
+		t.Fatalf("resolveHrefs failed: %v", err)
+	}
+
+	pngURI := "data:image/png;base64," + base64.StdEncoding.EncodeToString(pngData)
+	svgURI := "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString(svgData)
+	for _, want := range []string{
+		`<image xlink:href="` + pngURI + `"/>`,
+		`<image href='` + svgURI + `'/>`,
+		`<image href="` + pngURI + `"/>`,
+		`<image href="data:image/png;base64,AAAA"/>`,
+		`<use href="#local"/>`,
+		`<a href="https://example.com/">`,
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("Expected output to contain %s", want)
+		}
+	}
+
+	_, err = resolveHrefs(context.Background(), []byte(`<svg><image href="missing.png"/></svg>`), resolver)
+	if !errors.Is(err, ErrResourceNotFound) {
+		t.Fatalf("Expected ErrResourceNotFound, got %v", err)
+	}
+	var resvgErr *Error
+	if !errors.As(err, &resvgErr) || resvgErr.Path != "missing.png" {
+		t.Fatalf("Expected *Error with href, got %v", err)
+	}
+}
+
+func TestFSResolver(t *testing.T) {
+	resolver := FSResolver{FS: fstest.MapFS{
+		"images/a.png": {Data: []byte("a")},
+	}}
+
+	for _, href := range []string{"images/a.png", "./images/a.png", "/images/a.png"} {
+		data, err := resolver.Resolve(href)
+		if err != nil {
+			t.Fatalf("Resolve(%q) failed: %v", href, err)
+		}
+		if string(data) != "a" {
+			t.Fatalf("Resolve(%q) returned %q", href, data)
+		}
+	}
+
+	for _, href := range []string{"images/b.png", "../images/a.png"} {
+		if _, err := resolver.Resolve(href); !errors.Is(err, ErrResourceNotFound) {
+			t.Fatalf("Resolve(%q): expected ErrResourceNotFound, got %v", href, err)
+		}
+	}
+}
+
+func TestHTTPResolver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/assets/a.png" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("0123456789"))
+	}))
+	defer server.Close()
+
+	resolver := HTTPResolver{Client: server.Client(), BaseURL: server.URL + "/assets/"}
+
+	data, err := resolver.Resolve("a.png")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if string(data) != "0123456789" {
+		t.Fatalf("Resolve returned %q", data)
+	}
+
+	if _, err := resolver.Resolve(server.URL + "/assets/a.png"); err != nil {
+		t.Fatalf("Resolve of absolute URL failed: %v", err)
+	}
+
+	if _, err := resolver.Resolve("b.png"); !errors.Is(err, ErrResourceNotFound) {
+		t.Fatalf("Expected ErrResourceNotFound, got %v", err)
+	}
+
+	if _, err := (HTTPResolver{}).Resolve("file:///etc/passwd"); !errors.Is(err, ErrResourceNotFound) {
+		t.Fatalf("Expected ErrResourceNotFound for non-HTTP URL, got %v", err)
+	}
+
+	resolver.MaxBytes = 5
+	if _, err := resolver.Resolve("a.png"); !errors.Is(err, ErrInputTooLarge) {
+		t.Fatalf("Expected ErrInputTooLarge for response over MaxBytes, got %v", err)
+	}
+}
+
+func TestHTTPResolverContext(t *testing.T) {
+	// The server never responds, until the request is given up on
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	if (HTTPResolver{}).client().Timeout == 0 {
+		t.Fatal("Expected the default client to have a timeout")
+	}
+
+	resolver := HTTPResolver{Client: server.Client(), BaseURL: server.URL}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := resolver.ResolveContext(ctx, "a.png"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("ResolveContext took %v to return after the deadline", elapsed)
+	}
+
+	// ParseContext passes its context on, so the abandoned parse stops waiting for the server
+	opts := NewOptions()
+	defer opts.Close()
+	if err := opts.SetResolver(resolver); err != nil {
+		t.Fatalf("SetResolver failed: %v", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	doc := []byte(`<svg xmlns="http://www.w3.org/2000/svg"><image href="a.png"/></svg>`)
+	if _, err := ParseContext(ctx, doc, opts); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		opts.mu.Lock()
+		refs := opts.refs
+		opts.mu.Unlock()
+		if refs == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Abandoned parse is still waiting for the server")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSetResolver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(encodeTestPNG(t, color.NRGBA{0, 0, 255, 255}))
+	}))
+	defer server.Close()
+
+	opts := NewOptions()
+	defer opts.Close()
+	if err := opts.SetResolver(HTTPResolver{Client: server.Client(), BaseURL: server.URL}); err != nil {
+		t.Fatalf("SetResolver failed: %v", err)
+	}
+
+	tree, err := ParseFromData([]byte(`<svg width="4" height="4" xmlns="http://www.w3.org/2000/svg">
+		<image width="4" height="4" href="blue.png"/>
+	</svg>`), opts)
+	if err != nil {
+		t.Fatalf("ParseFromData failed: %v", err)
+	}
+	defer tree.Close()
+
+	img, err := tree.Render(IdentityTransform(), 4, 4)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if got := img.RGBAAt(2, 2); got != (color.RGBA{0, 0, 255, 255}) {
+		t.Fatalf("Expected resolved image to be drawn, got %v", got)
+	}
+}
@@ -0,0 +1,271 @@
+// Package sandbox renders SVGs in separate worker processes, so that a crash or abort inside
+// the native resvg library doesn't take down the calling process.
+//
+// Workers are binaries that call Serve, such as the one in cmd/resvg-worker. They communicate
+// with a Pool over their stdin and stdout.
+package sandbox
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os/exec"
+	"runtime"
+	"sync"
+
+	"github.com/thatoddmailbox/go-resvg"
+)
+
+// Error types
+var (
+	ErrRendererCrashed = errors.New("renderer crashed")
+	ErrPoolClosed      = errors.New("pool closed")
+)
+
+// Config configures a Pool
+type Config struct {
+	// Path is the path of the worker binary
+	Path string
+
+	// Args are passed to the worker binary
+	Args []string
+
+	// Env is the environment of the worker processes. If nil, they use the current process's environment.
+	Env []string
+
+	// Stderr receives the standard error of the worker processes, including any crash messages.
+	// If nil, it is discarded.
+	Stderr io.Writer
+
+	// Size is the maximum number of worker processes. If 0, runtime.NumCPU() is used.
+	Size int
+}
+
+// Pool renders SVGs using a pool of worker processes. Workers are started when needed, and a worker
+// that crashes or is killed is replaced by a new one on a later render. If an idle worker has died by the
+// time it's given a render, the render is retried once on a new worker rather than failing. It is safe for
+// concurrent use.
+type Pool struct {
+	config Config
+	slots  chan struct{}
+
+	mu      sync.Mutex
+	closed  bool
+	idle    []*worker
+	workers map[*worker]struct{}
+}
+
+type worker struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	killed sync.Once
+}
+
+// NewPool creates a Pool with the given configuration. No worker processes are started until the first render.
+func NewPool(config Config) *Pool {
+	if config.Size <= 0 {
+		config.Size = runtime.NumCPU()
+	}
+
+	return &Pool{
+		config:  config,
+		slots:   make(chan struct{}, config.Size),
+		workers: map[*worker]struct{}{},
+	}
+}
+
+// Render renders SVG data to an RGBA image at its natural size in a worker process.
+// If the worker crashes, the returned error wraps ErrRendererCrashed. If ctx is done before
+// the render finishes, the worker is killed and ctx.Err() is returned.
+func (p *Pool) Render(ctx context.Context, data []byte) (*image.RGBA, error) {
+	return p.render(ctx, request{data: data})
+}
+
+// RenderWithSize renders SVG data to an RGBA image with specified dimensions in a worker process.
+// If the worker crashes, the returned error wraps ErrRendererCrashed. If ctx is done before
+// the render finishes, the worker is killed and ctx.Err() is returned. A zero width or height fails
+// with an error wrapping resvg.ErrInvalidDimensions.
+func (p *Pool) RenderWithSize(ctx context.Context, data []byte, width, height uint32) (*image.RGBA, error) {
+	if width == 0 || height == 0 {
+		return nil, &resvg.Error{Op: "render", Err: fmt.Errorf("%w: %dx%d", resvg.ErrInvalidDimensions, width, height)}
+	}
+	return p.render(ctx, request{width: width, height: height, data: data})
+}
+
+// Close kills all worker processes. Renders in progress fail with ErrRendererCrashed,
+// and later renders fail with ErrPoolClosed.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	p.closed = true
+	workers := make([]*worker, 0, len(p.workers))
+	for w := range p.workers {
+		workers = append(workers, w)
+	}
+	p.idle = nil
+	p.mu.Unlock()
+
+	for _, w := range workers {
+		w.kill()
+	}
+	return nil
+}
+
+func (p *Pool) render(ctx context.Context, req request) (*image.RGBA, error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-p.slots }()
+
+	w, reused, err := p.acquireWorker(true)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, unanswered, err := w.do(ctx, req)
+	if err != nil && unanswered && reused {
+		// The idle worker died after its last request, before this one reached it, so nothing about this
+		// request made it fail. Try it once more on a new worker.
+		p.discardWorker(w)
+		if w, _, err = p.acquireWorker(false); err != nil {
+			return nil, err
+		}
+		resp, _, err = w.do(ctx, req)
+	}
+	if err != nil {
+		p.discardWorker(w)
+		return nil, err
+	}
+	p.releaseWorker(w)
+
+	if resp.err != nil {
+		return nil, resp.err
+	}
+
+	return &image.RGBA{
+		Pix:    resp.pix,
+		Stride: int(resp.width) * 4,
+		Rect:   image.Rect(0, 0, int(resp.width), int(resp.height)),
+	}, nil
+}
+
+// acquireWorker returns an idle worker if reuse is true and there is one, or starts a new one. reused
+// reports whether the worker was idle.
+func (p *Pool) acquireWorker(reuse bool) (w *worker, reused bool, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, false, ErrPoolClosed
+	}
+
+	if n := len(p.idle); reuse && n > 0 {
+		w = p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		return w, true, nil
+	}
+
+	w, err = p.startWorker()
+	if err != nil {
+		return nil, false, err
+	}
+	p.workers[w] = struct{}{}
+	return w, false, nil
+}
+
+// releaseWorker returns a worker to the idle list after a successful request
+func (p *Pool) releaseWorker(w *worker) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		delete(p.workers, w)
+		w.kill()
+		return
+	}
+	p.idle = append(p.idle, w)
+}
+
+// discardWorker kills a worker that crashed or was abandoned
+func (p *Pool) discardWorker(w *worker) {
+	p.mu.Lock()
+	delete(p.workers, w)
+	p.mu.Unlock()
+
+	w.kill()
+}
+
+func (p *Pool) startWorker() (*worker, error) {
+	cmd := exec.Command(p.config.Path, p.config.Args...)
+	cmd.Env = p.config.Env
+	cmd.Stderr = p.config.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start worker: %w", err)
+	}
+
+	return &worker{
+		cmd:    cmd,
+		stdin:  stdin,
+		stdout: bufio.NewReader(stdout),
+	}, nil
+}
+
+// do sends a request to the worker and waits for its response. If the exchange fails, unanswered reports
+// whether the worker was gone without responding at all: the request couldn't be written, or the worker's
+// output ended before any of a response.
+func (w *worker) do(ctx context.Context, req request) (resp response, unanswered bool, err error) {
+	type result struct {
+		resp       response
+		unanswered bool
+		err        error
+	}
+
+	results := make(chan result, 1)
+	go func() {
+		if err := writeRequest(w.stdin, req); err != nil {
+			results <- result{unanswered: true, err: err}
+			return
+		}
+		resp, err := readResponse(w.stdout)
+		results <- result{resp, errors.Is(err, io.EOF), err}
+	}()
+
+	select {
+	case r := <-results:
+		if r.err != nil {
+			// The worker can't be trusted after a broken exchange, so make sure it's gone
+			w.kill()
+			return response{}, r.unanswered, fmt.Errorf("%w: %v (%v)", ErrRendererCrashed, r.err, w.cmd.ProcessState)
+		}
+		return r.resp, false, nil
+	case <-ctx.Done():
+		// Killing the worker unblocks the exchange
+		w.kill()
+		<-results
+		return response{}, false, ctx.Err()
+	}
+}
+
+// kill stops the worker process and waits for it to exit. It is safe to call more than once,
+// including concurrently.
+func (w *worker) kill() {
+	w.killed.Do(func() {
+		w.stdin.Close()
+		w.cmd.Process.Kill()
+		w.cmd.Wait()
+	})
+}
@@ -0,0 +1,193 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image/color"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/thatoddmailbox/go-resvg"
+)
+
+// The test binary doubles as the worker binary when this environment variable is set
+const workerEnv = "RESVG_SANDBOX_TEST_WORKER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(workerEnv) == "1" {
+		if err := Serve(os.Stdin, os.Stdout, nil); err != nil {
+			fmt.Fprintf(os.Stderr, "worker: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func newTestPool(size int) *Pool {
+	return NewPool(Config{
+		Path:   os.Args[0],
+		Env:    append(os.Environ(), workerEnv+"=1"),
+		Stderr: os.Stderr,
+		Size:   size,
+	})
+}
+
+var testSVG = []byte(`<svg width="10" height="10" xmlns="http://www.w3.org/2000/svg">
+	<rect width="10" height="10" fill="red"/>
+</svg>`)
+
+// slowSVG takes a long time to render because of the large, repeated blurs
+var slowSVG = []byte(`<svg width="4000" height="4000" xmlns="http://www.w3.org/2000/svg">
+	<defs>
+		<filter id="blur" x="-50%" y="-50%" width="200%" height="200%">
+			<feGaussianBlur stdDeviation="400"/>
+			<feGaussianBlur stdDeviation="400"/>
+			<feGaussianBlur stdDeviation="400"/>
+			<feGaussianBlur stdDeviation="400"/>
+		</filter>
+	</defs>
+	<g filter="url(#blur)"><g filter="url(#blur)"><g filter="url(#blur)"><g filter="url(#blur)">
+		<rect width="4000" height="4000" fill="red"/>
+	</g></g></g></g>
+</svg>`)
+
+func TestPoolRender(t *testing.T) {
+	pool := newTestPool(2)
+	defer pool.Close()
+
+	img, err := pool.Render(context.Background(), testSVG)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != 10 || bounds.Dy() != 10 {
+		t.Fatalf("Expected 10x10 image, got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+	if got := img.RGBAAt(5, 5); got != (color.RGBA{255, 0, 0, 255}) {
+		t.Fatalf("Pixel incorrect: %v", got)
+	}
+
+	img, err = pool.RenderWithSize(context.Background(), testSVG, 20, 30)
+	if err != nil {
+		t.Fatalf("RenderWithSize failed: %v", err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != 20 || bounds.Dy() != 30 {
+		t.Fatalf("Expected 20x30 image, got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+
+	if _, err := pool.Render(context.Background(), []byte("not svg")); !errors.Is(err, resvg.ErrParsingFailed) {
+		t.Fatalf("Expected ErrParsingFailed, got %v", err)
+	}
+
+	_, err = pool.RenderWithSize(context.Background(), testSVG, 0, 30)
+	var resvgErr *resvg.Error
+	if !errors.Is(err, resvg.ErrInvalidDimensions) || !errors.As(err, &resvgErr) || resvgErr.Op != "render" {
+		t.Fatalf("Expected *resvg.Error wrapping ErrInvalidDimensions, got %v", err)
+	}
+}
+
+func TestPoolWorkerCrash(t *testing.T) {
+	pool := newTestPool(1)
+	defer pool.Close()
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := pool.Render(context.Background(), slowSVG)
+		errs <- err
+	}()
+
+	// Kill the worker while it's rendering
+	w := waitForWorker(t, pool)
+	if err := w.cmd.Process.Kill(); err != nil {
+		t.Fatalf("Failed to kill worker: %v", err)
+	}
+
+	if err := <-errs; !errors.Is(err, ErrRendererCrashed) {
+		t.Fatalf("Expected ErrRendererCrashed, got %v", err)
+	}
+
+	// The pool should recover by starting a new worker
+	if _, err := pool.Render(context.Background(), testSVG); err != nil {
+		t.Fatalf("Render after crash failed: %v", err)
+	}
+}
+
+func TestPoolIdleWorkerCrash(t *testing.T) {
+	pool := newTestPool(1)
+	defer pool.Close()
+
+	if _, err := pool.Render(context.Background(), testSVG); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	w := waitForWorker(t, pool)
+	if err := w.cmd.Process.Kill(); err != nil {
+		t.Fatalf("Failed to kill worker: %v", err)
+	}
+
+	// The request never reached the dead worker, so it's retried on a new one
+	if _, err := pool.Render(context.Background(), testSVG); err != nil {
+		t.Fatalf("Render after idle worker crash failed: %v", err)
+	}
+	if got := waitForWorker(t, pool); got == w {
+		t.Fatal("Expected the dead worker to be replaced")
+	}
+	if _, err := pool.Render(context.Background(), testSVG); err != nil {
+		t.Fatalf("Render after crash failed: %v", err)
+	}
+}
+
+func TestPoolContext(t *testing.T) {
+	pool := newTestPool(1)
+	defer pool.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := pool.Render(ctx, slowSVG)
+	if err == nil {
+		t.Skip("Render finished before the deadline")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("Render took %v to return after the deadline", elapsed)
+	}
+
+	if _, err := pool.Render(context.Background(), testSVG); err != nil {
+		t.Fatalf("Render after cancellation failed: %v", err)
+	}
+}
+
+func TestPoolClose(t *testing.T) {
+	pool := newTestPool(1)
+	if _, err := pool.Render(context.Background(), testSVG); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	pool.Close()
+	if _, err := pool.Render(context.Background(), testSVG); !errors.Is(err, ErrPoolClosed) {
+		t.Fatalf("Expected ErrPoolClosed, got %v", err)
+	}
+}
+
+// waitForWorker waits until the pool has started a worker and returns it
+func waitForWorker(t *testing.T, pool *Pool) *worker {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		pool.mu.Lock()
+		for w := range pool.workers {
+			pool.mu.Unlock()
+			return w
+		}
+		pool.mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("Timed out waiting for a worker to start")
+	return nil
+}
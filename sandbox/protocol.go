@@ -0,0 +1,189 @@
+package sandbox
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/thatoddmailbox/go-resvg"
+)
+
+// Each message is sent as a frame: a 4 byte big-endian length, followed by that many bytes of body.
+//
+// A request body is the requested width and height (4 bytes each, big-endian, 0x0 for the natural size)
+// followed by the SVG data. A response body starts with a status byte. On success, it is followed by the
+// width and height of the image (4 bytes each) and its premultiplied RGBA8888 pixels. On failure, it is
+// followed by an error code byte and the error message.
+
+const (
+	statusOK    = 0
+	statusError = 1
+)
+
+// errorCodes maps the package's sentinel errors to the codes used on the wire. Code 0 is an error that
+// doesn't match any of them.
+var errorCodes = []error{
+	nil,
+	resvg.ErrNotUTF8,
+	resvg.ErrFileOpenFailed,
+	resvg.ErrMalformedGzip,
+	resvg.ErrElementsLimit,
+	resvg.ErrInvalidSize,
+	resvg.ErrParsingFailed,
+	resvg.ErrTooManyPixels,
+	resvg.ErrEmptyData,
+	resvg.ErrEmptyImage,
+	resvg.ErrNodeNotFound,
+	resvg.ErrInvalidDimensions,
+	resvg.ErrResourceNotFound,
+	resvg.ErrResourceBlocked,
+	resvg.ErrInputTooLarge,
+	resvg.ErrDecompressedTooLarge,
+	resvg.ErrTooDeep,
+	resvg.ErrTooManyElements,
+	resvg.ErrEntityExpansion,
+}
+
+type request struct {
+	width, height uint32
+	data          []byte
+}
+
+type response struct {
+	width, height uint32
+	pix           []byte
+	err           error
+}
+
+// remoteError is an error returned by a worker
+type remoteError struct {
+	message string
+	err     error
+}
+
+func (e *remoteError) Error() string {
+	return e.message
+}
+
+func (e *remoteError) Unwrap() error {
+	return e.err
+}
+
+func writeFrame(w io.Writer, parts ...[]byte) error {
+	length := 0
+	for _, part := range parts {
+		length += len(part)
+	}
+	if uint64(length) > math.MaxUint32 {
+		return fmt.Errorf("frame of %d bytes is too large", length)
+	}
+
+	var header [4]byte
+	binary.BigEndian.PutUint32(header[:], uint32(length))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	for _, part := range parts {
+		if _, err := w.Write(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readFrame(r io.Reader) ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+
+	body := make([]byte, binary.BigEndian.Uint32(header[:]))
+	if _, err := io.ReadFull(r, body); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return body, nil
+}
+
+func writeRequest(w io.Writer, req request) error {
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[0:4], req.width)
+	binary.BigEndian.PutUint32(header[4:8], req.height)
+	return writeFrame(w, header[:], req.data)
+}
+
+func readRequest(r io.Reader) (request, error) {
+	body, err := readFrame(r)
+	if err != nil {
+		return request{}, err
+	}
+	if len(body) < 8 {
+		return request{}, fmt.Errorf("request of %d bytes is too short", len(body))
+	}
+
+	return request{
+		width:  binary.BigEndian.Uint32(body[0:4]),
+		height: binary.BigEndian.Uint32(body[4:8]),
+		data:   body[8:],
+	}, nil
+}
+
+func writeResponse(w io.Writer, resp response) error {
+	if resp.err != nil {
+		code := 0
+		for i, sentinel := range errorCodes {
+			if sentinel != nil && errors.Is(resp.err, sentinel) {
+				code = i
+				break
+			}
+		}
+		return writeFrame(w, []byte{statusError, byte(code)}, []byte(resp.err.Error()))
+	}
+
+	var header [9]byte
+	header[0] = statusOK
+	binary.BigEndian.PutUint32(header[1:5], resp.width)
+	binary.BigEndian.PutUint32(header[5:9], resp.height)
+	return writeFrame(w, header[:], resp.pix)
+}
+
+func readResponse(r io.Reader) (response, error) {
+	body, err := readFrame(r)
+	if err != nil {
+		return response{}, err
+	}
+	if len(body) < 1 {
+		return response{}, errors.New("empty response")
+	}
+
+	switch body[0] {
+	case statusOK:
+		if len(body) < 9 {
+			return response{}, fmt.Errorf("response of %d bytes is too short", len(body))
+		}
+		resp := response{
+			width:  binary.BigEndian.Uint32(body[1:5]),
+			height: binary.BigEndian.Uint32(body[5:9]),
+			pix:    body[9:],
+		}
+		if uint64(len(resp.pix)) != uint64(resp.width)*uint64(resp.height)*4 {
+			return response{}, fmt.Errorf("response has %d bytes of pixels for a %dx%d image", len(resp.pix), resp.width, resp.height)
+		}
+		return resp, nil
+	case statusError:
+		if len(body) < 2 {
+			return response{}, errors.New("error response is too short")
+		}
+		var sentinel error
+		if code := int(body[1]); code < len(errorCodes) {
+			sentinel = errorCodes[code]
+		}
+		return response{err: &remoteError{message: string(body[2:]), err: sentinel}}, nil
+	default:
+		return response{}, fmt.Errorf("unknown response status %d", body[0])
+	}
+}
@@ -0,0 +1,80 @@
+package sandbox
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/thatoddmailbox/go-resvg"
+)
+
+func TestRequestRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	req := request{width: 640, height: 480, data: []byte("<svg/>")}
+	if err := writeRequest(&buf, req); err != nil {
+		t.Fatalf("writeRequest failed: %v", err)
+	}
+
+	got, err := readRequest(&buf)
+	if err != nil {
+		t.Fatalf("readRequest failed: %v", err)
+	}
+	if got.width != req.width || got.height != req.height || !bytes.Equal(got.data, req.data) {
+		t.Fatalf("Request incorrect: expected %+v, got %+v", req, got)
+	}
+
+	if _, err := readRequest(&buf); !errors.Is(err, io.EOF) {
+		t.Fatalf("Expected io.EOF at end of stream, got %v", err)
+	}
+}
+
+func TestResponseRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	resp := response{width: 1, height: 2, pix: []byte{1, 2, 3, 4, 5, 6, 7, 8}}
+	if err := writeResponse(&buf, resp); err != nil {
+		t.Fatalf("writeResponse failed: %v", err)
+	}
+
+	got, err := readResponse(&buf)
+	if err != nil {
+		t.Fatalf("readResponse failed: %v", err)
+	}
+	if got.width != 1 || got.height != 2 || !bytes.Equal(got.pix, resp.pix) || got.err != nil {
+		t.Fatalf("Response incorrect: %+v", got)
+	}
+
+	// Errors should keep working with errors.Is on the other side
+	if err := writeResponse(&buf, response{err: resvg.ErrParsingFailed}); err != nil {
+		t.Fatalf("writeResponse failed: %v", err)
+	}
+	got, err = readResponse(&buf)
+	if err != nil {
+		t.Fatalf("readResponse failed: %v", err)
+	}
+	if !errors.Is(got.err, resvg.ErrParsingFailed) || got.err.Error() != resvg.ErrParsingFailed.Error() {
+		t.Fatalf("Expected ErrParsingFailed, got %v", got.err)
+	}
+}
+
+func TestTruncatedFrame(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeRequest(&buf, request{data: []byte("<svg/>")}); err != nil {
+		t.Fatalf("writeRequest failed: %v", err)
+	}
+	buf.Truncate(buf.Len() - 1)
+
+	if _, err := readRequest(&buf); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("Expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+func TestMismatchedPixels(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeResponse(&buf, response{width: 2, height: 2, pix: []byte{1, 2, 3, 4}}); err != nil {
+		t.Fatalf("writeResponse failed: %v", err)
+	}
+	if _, err := readResponse(&buf); err == nil {
+		t.Fatal("Expected error for response with too few pixels")
+	}
+}
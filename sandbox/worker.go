@@ -0,0 +1,58 @@
+package sandbox
+
+import (
+	"bufio"
+	"errors"
+	"image"
+	"io"
+
+	"github.com/thatoddmailbox/go-resvg"
+)
+
+// Serve runs the worker side of the protocol: it reads render requests from r, renders them with
+// renderer and writes the responses to w, until r is closed. If renderer is nil, a renderer using
+// the shared default options is used. Worker binaries should call Serve with os.Stdin and os.Stdout.
+func Serve(r io.Reader, w io.Writer, renderer *resvg.Renderer) error {
+	if renderer == nil {
+		renderer = resvg.NewRenderer(nil)
+	}
+
+	reader := bufio.NewReader(r)
+	writer := bufio.NewWriter(w)
+	for {
+		req, err := readRequest(reader)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := writeResponse(writer, handle(renderer, req)); err != nil {
+			return err
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+	}
+}
+
+func handle(renderer *resvg.Renderer, req request) response {
+	var img *image.RGBA
+	var err error
+	if req.width == 0 && req.height == 0 {
+		img, err = renderer.Render(req.data)
+	} else {
+		img, err = renderer.RenderWithSize(req.data, req.width, req.height)
+	}
+	if err != nil {
+		return response{err: err}
+	}
+
+	bounds := img.Bounds()
+	return response{
+		width:  uint32(bounds.Dx()),
+		height: uint32(bounds.Dy()),
+		pix:    img.Pix,
+	}
+}
@@ -0,0 +1,194 @@
+package resvg
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Tile is one tile of a tiled render
+type Tile struct {
+	// Column and Row are the position of the tile in the grid of tiles, starting from the top left
+	Column, Row int
+
+	// Image holds the premultiplied pixels of the tile. Its bounds are the area of the full output that
+	// the tile covers. The memory is reused for the next tile, so the image is only valid until the
+	// callback it's passed to returns.
+	Image *image.RGBA
+}
+
+// RenderTiles renders the SVG tree, transformed by transform, as a width x height output split into tiles
+// of at most tileSize x tileSize pixels. fn is called with each tile in turn, row by row, and only one
+// tile is held in memory at a time, so outputs far larger than a single Render could allocate can be
+// produced. If fn returns an error, rendering stops and the error is returned.
+//
+// Each tile is rendered through the same tree with an offset transform, so shapes join up across tiles
+// without seams. Filters such as blurs are clipped to the tile they're rendered in, though, so they may not
+// join up where they cross from one tile into another.
+func (t *RenderTree) RenderTiles(transform Transform, width, height, tileSize uint32, fn func(Tile) error) error {
+	if width == 0 || height == 0 || tileSize == 0 {
+		return &Error{Op: "render", Err: fmt.Errorf("%w: %dx%d in %dx%d tiles", ErrInvalidSize, width, height, tileSize, tileSize)}
+	}
+	tileWidth, tileHeight := minUint32(tileSize, width), minUint32(tileSize, height)
+	if err := t.checkSize(tileWidth, tileHeight); err != nil {
+		return err
+	}
+
+	buf := make([]byte, int(tileWidth)*int(tileHeight)*4)
+	for row, y := 0, 0; y < int(height); row, y = row+1, y+int(tileSize) {
+		for column, x := 0, 0; x < int(width); column, x = column+1, x+int(tileSize) {
+			bounds := image.Rect(x, y, x+int(tileSize), y+int(tileSize)).Intersect(image.Rect(0, 0, int(width), int(height)))
+			w, h := bounds.Dx(), bounds.Dy()
+
+			pix := buf[:w*h*4]
+			tileTransform := transform.Then(Translate(-float32(x), -float32(y)))
+			if err := t.RenderToBytes(pix, w*4, uint32(w), uint32(h), tileTransform); err != nil {
+				return err
+			}
+
+			tile := Tile{
+				Column: column,
+				Row:    row,
+				Image:  &image.RGBA{Pix: pix, Stride: w * 4, Rect: bounds},
+			}
+			if err := fn(tile); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// PyramidLayout selects how WritePyramid lays out the tiles of a pyramid on disk
+type PyramidLayout int
+
+const (
+	// LayoutXYZ writes tiles to z/x/y.png, as used by web map viewers. Zoom level 0 fits in a single tile,
+	// and each level after it doubles the size, up to the full size of the output.
+	LayoutXYZ PyramidLayout = iota
+
+	// LayoutDZI writes a Deep Zoom image: a name.dzi descriptor, and tiles in name_files/level/x_y.png.
+	// Level 0 is a single pixel, and each level after it doubles the size, up to the full size of the output.
+	LayoutDZI
+)
+
+// PyramidOptions configures WritePyramid
+type PyramidOptions struct {
+	// Layout is the directory layout and level numbering of the pyramid
+	Layout PyramidLayout
+
+	// TileSize is the width and height of the tiles, in pixels. If 0, 256 is used.
+	TileSize uint32
+
+	// Name is the name of the Deep Zoom image with LayoutDZI. If empty, "image" is used.
+	Name string
+}
+
+// WritePyramid renders the SVG tree, transformed by transform, as a width x height output into a pyramid of
+// PNG tiles in dir, for zoomable viewers. Every level of the pyramid is rendered from the document rather
+// than downsampled from the level above, and only one tile is held in memory at a time.
+func (t *RenderTree) WritePyramid(dir string, transform Transform, width, height uint32, opts PyramidOptions) error {
+	tileSize := opts.TileSize
+	if tileSize == 0 {
+		tileSize = 256
+	}
+	name := opts.Name
+	if name == "" {
+		name = "image"
+	}
+
+	if width == 0 || height == 0 {
+		return &Error{Op: "render", Err: fmt.Errorf("%w: %dx%d", ErrInvalidSize, width, height)}
+	}
+
+	// maxLevel is the number of times the full size can be halved before it reaches the smallest level
+	smallest := tileSize
+	if opts.Layout == LayoutDZI {
+		smallest = 1
+	}
+	maxLevel := 0
+	for scaleDown(maxUint32(width, height), maxLevel) > smallest {
+		maxLevel++
+	}
+
+	if opts.Layout == LayoutDZI {
+		if err := writeDZIDescriptor(filepath.Join(dir, name+".dzi"), width, height, tileSize); err != nil {
+			return err
+		}
+	}
+
+	for level := 0; level <= maxLevel; level++ {
+		shift := maxLevel - level
+		scale := 1 / float32(uint64(1)<<shift)
+		levelTransform := transform.Then(Scale(scale, scale))
+		levelWidth, levelHeight := scaleDown(width, shift), scaleDown(height, shift)
+
+		err := t.RenderTiles(levelTransform, levelWidth, levelHeight, tileSize, func(tile Tile) error {
+			var path string
+			if opts.Layout == LayoutDZI {
+				path = filepath.Join(dir, name+"_files", strconv.Itoa(level), fmt.Sprintf("%d_%d.png", tile.Column, tile.Row))
+			} else {
+				path = filepath.Join(dir, strconv.Itoa(level), strconv.Itoa(tile.Column), strconv.Itoa(tile.Row)+".png")
+			}
+			return writeTilePNG(path, tile.Image)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeTilePNG writes img to a new PNG file at path, creating its directory if needed
+func writeTilePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeDZIDescriptor writes the XML file that describes a Deep Zoom image
+func writeDZIDescriptor(path string, width, height, tileSize uint32) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	descriptor := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<Image xmlns="http://schemas.microsoft.com/deepzoom/2008" Format="png" Overlap="0" TileSize="%d">
+  <Size Width="%d" Height="%d"/>
+</Image>
+`, tileSize, width, height)
+	return os.WriteFile(path, []byte(descriptor), 0o644)
+}
+
+// scaleDown returns v halved shift times, rounding up
+func scaleDown(v uint32, shift int) uint32 {
+	if shift >= 32 {
+		return 1
+	}
+	return uint32((uint64(v) + (uint64(1) << shift) - 1) >> shift)
+}
+
+func minUint32(a, b uint32) uint32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxUint32(a, b uint32) uint32 {
+	if a > b {
+		return a
+	}
+	return b
+}
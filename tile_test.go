@@ -0,0 +1,161 @@
+package resvg
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// tileTestSVG has antialiased edges crossing tile boundaries in every direction
+const tileTestSVG = `<svg width="100" height="60" xmlns="http://www.w3.org/2000/svg">
+	<circle cx="40" cy="30" r="23.3" fill="red" fill-opacity="0.7"/>
+	<rect x="55.5" y="8.25" width="30" height="30" transform="rotate(17 70 23)" fill="blue" stroke="green" stroke-width="3.3"/>
+	<path d="M 3 57 Q 50 -20 97 57" fill="none" stroke="black" stroke-width="1.7"/>
+</svg>`
+
+func TestRenderTiles(t *testing.T) {
+	opts := NewOptions()
+	defer opts.Close()
+
+	tree, err := ParseFromData([]byte(tileTestSVG), opts)
+	if err != nil {
+		t.Fatalf("ParseFromData failed: %v", err)
+	}
+	defer tree.Close()
+
+	transform := Scale(2, 2).Then(Translate(3, 5))
+	const width, height = 203, 125
+
+	full, err := tree.Render(transform, width, height)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	for _, tileSize := range []uint32{16, 50, 64, 203, 1000} {
+		assembled := image.NewRGBA(image.Rect(0, 0, width, height))
+		var tiles int
+		err := tree.RenderTiles(transform, width, height, tileSize, func(tile Tile) error {
+			bounds := tile.Image.Bounds()
+			if bounds.Min.X != tile.Column*int(tileSize) || bounds.Min.Y != tile.Row*int(tileSize) {
+				t.Fatalf("Tile %d,%d has bounds %v", tile.Column, tile.Row, bounds)
+			}
+			draw.Draw(assembled, bounds, tile.Image, bounds.Min, draw.Src)
+			tiles++
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("RenderTiles failed: %v", err)
+		}
+
+		columns := (width + int(tileSize) - 1) / int(tileSize)
+		rows := (height + int(tileSize) - 1) / int(tileSize)
+		if tiles != columns*rows {
+			t.Fatalf("Expected %d tiles of %d, got %d", columns*rows, tileSize, tiles)
+		}
+
+		if !bytes.Equal(assembled.Pix, full.Pix) {
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					if assembled.RGBAAt(x, y) != full.RGBAAt(x, y) {
+						t.Fatalf("Tiles of %d differ from the full render at (%d, %d): %v != %v",
+							tileSize, x, y, assembled.RGBAAt(x, y), full.RGBAAt(x, y))
+					}
+				}
+			}
+		}
+	}
+
+	if err := tree.RenderTiles(transform, width, height, 0, func(Tile) error { return nil }); err == nil {
+		t.Fatal("Expected error for zero tile size")
+	}
+}
+
+func TestWritePyramid(t *testing.T) {
+	opts := NewOptions()
+	defer opts.Close()
+
+	tree, err := ParseFromData([]byte(tileTestSVG), opts)
+	if err != nil {
+		t.Fatalf("ParseFromData failed: %v", err)
+	}
+	defer tree.Close()
+
+	// 400x240 with 64 pixel tiles has levels of 400x240, 200x120, 100x60 and 50x30
+	dir := t.TempDir()
+	if err := tree.WritePyramid(dir, Scale(4, 4), 400, 240, PyramidOptions{TileSize: 64}); err != nil {
+		t.Fatalf("WritePyramid failed: %v", err)
+	}
+	checkTile(t, filepath.Join(dir, "0", "0", "0.png"), 50, 30)
+	checkTile(t, filepath.Join(dir, "1", "1", "0.png"), 36, 60)
+	checkTile(t, filepath.Join(dir, "3", "6", "3.png"), 16, 48)
+	if _, err := os.Stat(filepath.Join(dir, "4")); !os.IsNotExist(err) {
+		t.Fatalf("Expected no level 4, got %v", err)
+	}
+
+	dir = t.TempDir()
+	if err := tree.WritePyramid(dir, Scale(4, 4), 400, 240, PyramidOptions{Layout: LayoutDZI, TileSize: 64, Name: "poster"}); err != nil {
+		t.Fatalf("WritePyramid failed: %v", err)
+	}
+	descriptor, err := os.ReadFile(filepath.Join(dir, "poster.dzi"))
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	for _, want := range []string{`TileSize="64"`, `Overlap="0"`, `Format="png"`, `Width="400"`, `Height="240"`} {
+		if !strings.Contains(string(descriptor), want) {
+			t.Fatalf("Expected descriptor to contain %s, got %s", want, descriptor)
+		}
+	}
+
+	// DZI levels go down to a single pixel: 400x240 is level 9, and level 0 is 1x1
+	checkTile(t, filepath.Join(dir, "poster_files", "0", "0_0.png"), 1, 1)
+	checkTile(t, filepath.Join(dir, "poster_files", "7", "1_0.png"), 36, 60)
+	checkTile(t, filepath.Join(dir, "poster_files", "9", "6_3.png"), 16, 48)
+	if _, err := os.Stat(filepath.Join(dir, "poster_files", "10")); !os.IsNotExist(err) {
+		t.Fatalf("Expected no level 10, got %v", err)
+	}
+}
+
+func checkTile(t *testing.T, path string, width, height int) {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer f.Close()
+
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("png.Decode failed: %v", err)
+	}
+	if img.Bounds().Dx() != width || img.Bounds().Dy() != height {
+		t.Fatalf("Expected %s to be %dx%d, got %v", path, width, height, img.Bounds())
+	}
+}
+
+func TestScaleDown(t *testing.T) {
+	tests := []struct {
+		v     uint32
+		shift int
+		want  uint32
+	}{
+		{400, 0, 400},
+		{400, 1, 200},
+		{401, 1, 201},
+		{401, 3, 51},
+		{1, 5, 1},
+		{4294967295, 31, 2},
+		{4294967295, 32, 1},
+	}
+
+	for _, test := range tests {
+		if got := scaleDown(test.v, test.shift); got != test.want {
+			t.Errorf("scaleDown(%d, %d) = %d, expected %d", test.v, test.shift, got, test.want)
+		}
+	}
+}
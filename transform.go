@@ -0,0 +1,105 @@
+package resvg
+
+import "math"
+
+// Translate returns a transform that moves points by (tx, ty)
+func Translate(tx, ty float32) Transform {
+	return Transform{A: 1, D: 1, E: tx, F: ty}
+}
+
+// Scale returns a transform that scales points by sx horizontally and sy vertically
+func Scale(sx, sy float32) Transform {
+	return Transform{A: sx, D: sy}
+}
+
+// Rotate returns a transform that rotates points clockwise, in the SVG coordinate system, by deg degrees
+// around the origin
+func Rotate(deg float32) Transform {
+	sin, cos := math.Sincos(float64(deg) * math.Pi / 180)
+	return Transform{A: float32(cos), B: float32(sin), C: float32(-sin), D: float32(cos)}
+}
+
+// SkewX returns a transform that skews points along the x axis by deg degrees
+func SkewX(deg float32) Transform {
+	return Transform{A: 1, C: float32(math.Tan(float64(deg) * math.Pi / 180)), D: 1}
+}
+
+// SkewY returns a transform that skews points along the y axis by deg degrees
+func SkewY(deg float32) Transform {
+	return Transform{A: 1, B: float32(math.Tan(float64(deg) * math.Pi / 180)), D: 1}
+}
+
+// Multiply returns the matrix product t * other: a transform that applies other first, then t
+func (t Transform) Multiply(other Transform) Transform {
+	a, b, c, d, e, f := t.float64s()
+	oa, ob, oc, od, oe, of := other.float64s()
+	return Transform{
+		A: float32(a*oa + c*ob),
+		B: float32(b*oa + d*ob),
+		C: float32(a*oc + c*od),
+		D: float32(b*oc + d*od),
+		E: float32(a*oe + c*of + e),
+		F: float32(b*oe + d*of + f),
+	}
+}
+
+// Then returns a transform that applies t first, then next. It's the same as next.Multiply(t), so that
+// Scale(2, 2).Then(Translate(10, 0)) scales and then translates.
+func (t Transform) Then(next Transform) Transform {
+	return next.Multiply(t)
+}
+
+// Invert returns the inverse of t, or false if t can't be inverted because it collapses the plane
+// into a line or a point
+func (t Transform) Invert() (Transform, bool) {
+	a, b, c, d, e, f := t.float64s()
+	det := a*d - b*c
+	if det == 0 || math.IsNaN(det) || math.IsInf(det, 0) {
+		return Transform{}, false
+	}
+	return Transform{
+		A: float32(d / det),
+		B: float32(-b / det),
+		C: float32(-c / det),
+		D: float32(a / det),
+		E: float32((c*f - d*e) / det),
+		F: float32((b*e - a*f) / det),
+	}, true
+}
+
+// ApplyPoint returns the point (x, y) transformed by t
+func (t Transform) ApplyPoint(x, y float32) (float32, float32) {
+	a, b, c, d, e, f := t.float64s()
+	px, py := float64(x), float64(y)
+	return float32(a*px + c*py + e), float32(b*px + d*py + f)
+}
+
+// ApplyRect returns the axis-aligned bounding box of r transformed by t
+func (t Transform) ApplyRect(r Rect) Rect {
+	corners := [4][2]float32{
+		{r.X, r.Y},
+		{r.X + r.Width, r.Y},
+		{r.X, r.Y + r.Height},
+		{r.X + r.Width, r.Y + r.Height},
+	}
+
+	minX, minY := float32(math.Inf(1)), float32(math.Inf(1))
+	maxX, maxY := float32(math.Inf(-1)), float32(math.Inf(-1))
+	for _, corner := range corners {
+		x, y := t.ApplyPoint(corner[0], corner[1])
+		minX, maxX = minFloat32(minX, x), maxFloat32(maxX, x)
+		minY, maxY = minFloat32(minY, y), maxFloat32(maxY, y)
+	}
+	return Rect{X: minX, Y: minY, Width: maxX - minX, Height: maxY - minY}
+}
+
+// IsIdentity reports whether t leaves every point where it is
+func (t Transform) IsIdentity() bool {
+	return t == Transform{A: 1, D: 1}
+}
+
+// float64s returns the fields of t as float64s, so that combining transforms doesn't lose precision
+// to intermediate rounding
+func (t Transform) float64s() (a, b, c, d, e, f float64) {
+	return float64(t.A), float64(t.B), float64(t.C), float64(t.D), float64(t.E), float64(t.F)
+}
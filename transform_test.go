@@ -0,0 +1,162 @@
+package resvg
+
+import (
+	"math"
+	"testing"
+)
+
+const transformEpsilon = 1e-4
+
+func transformsEqual(a, b Transform) bool {
+	return floatsEqual(a.A, b.A) && floatsEqual(a.B, b.B) && floatsEqual(a.C, b.C) &&
+		floatsEqual(a.D, b.D) && floatsEqual(a.E, b.E) && floatsEqual(a.F, b.F)
+}
+
+func floatsEqual(a, b float32) bool {
+	return math.Abs(float64(a)-float64(b)) < transformEpsilon
+}
+
+func TestTransformConstructors(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform Transform
+		x, y      float32
+		wantX     float32
+		wantY     float32
+	}{
+		{"translate", Translate(10, -5), 1, 2, 11, -3},
+		{"scale", Scale(2, 3), 1, 2, 2, 6},
+		{"scale negative", Scale(-1, 1), 4, 2, -4, 2},
+		{"rotate 90", Rotate(90), 1, 0, 0, 1},
+		{"rotate 180", Rotate(180), 1, 2, -1, -2},
+		{"rotate -90", Rotate(-90), 1, 0, 0, -1},
+		{"rotate 360", Rotate(360), 3, 4, 3, 4},
+		{"skew x 45", SkewX(45), 0, 2, 2, 2},
+		{"skew y 45", SkewY(45), 2, 0, 2, 2},
+		{"skew x 0", SkewX(0), 3, 4, 3, 4},
+	}
+
+	for _, test := range tests {
+		x, y := test.transform.ApplyPoint(test.x, test.y)
+		if !floatsEqual(x, test.wantX) || !floatsEqual(y, test.wantY) {
+			t.Errorf("%s: (%v, %v) mapped to (%v, %v), expected (%v, %v)",
+				test.name, test.x, test.y, x, y, test.wantX, test.wantY)
+		}
+	}
+}
+
+func TestTransformMultiply(t *testing.T) {
+	tests := []struct {
+		name  string
+		got   Transform
+		want  Transform
+		point [2]float32
+		moved [2]float32
+	}{
+		{"identity", IdentityTransform().Multiply(Translate(1, 2)), Translate(1, 2), [2]float32{0, 0}, [2]float32{1, 2}},
+		{"translations add", Translate(1, 2).Multiply(Translate(3, 4)), Translate(4, 6), [2]float32{0, 0}, [2]float32{4, 6}},
+		{"scales multiply", Scale(2, 3).Multiply(Scale(4, 5)), Scale(8, 15), [2]float32{1, 1}, [2]float32{8, 15}},
+		{"multiply applies the argument first", Translate(10, 0).Multiply(Scale(2, 2)),
+			Transform{A: 2, D: 2, E: 10}, [2]float32{1, 1}, [2]float32{12, 2}},
+		{"then applies the argument last", Translate(10, 0).Then(Scale(2, 2)),
+			Transform{A: 2, D: 2, E: 20}, [2]float32{1, 1}, [2]float32{22, 2}},
+		{"rotations add", Rotate(30).Then(Rotate(60)), Rotate(90), [2]float32{1, 0}, [2]float32{0, 1}},
+	}
+
+	for _, test := range tests {
+		if !transformsEqual(test.got, test.want) {
+			t.Errorf("%s: got %+v, expected %+v", test.name, test.got, test.want)
+		}
+		x, y := test.got.ApplyPoint(test.point[0], test.point[1])
+		if !floatsEqual(x, test.moved[0]) || !floatsEqual(y, test.moved[1]) {
+			t.Errorf("%s: %v mapped to (%v, %v), expected %v", test.name, test.point, x, y, test.moved)
+		}
+	}
+}
+
+func TestTransformInvert(t *testing.T) {
+	tests := []struct {
+		name       string
+		transform  Transform
+		invertible bool
+	}{
+		{"identity", IdentityTransform(), true},
+		{"translate", Translate(10, -5), true},
+		{"scale", Scale(2, 0.5), true},
+		{"rotate", Rotate(33), true},
+		{"skew", SkewX(20).Then(SkewY(-10)), true},
+		{"combined", Scale(3, 2).Then(Rotate(45)).Then(Translate(7, 8)), true},
+		{"zero scale", Scale(0, 1), false},
+		{"collapsed", Transform{A: 1, B: 2, C: 2, D: 4}, false},
+		{"zero", Transform{}, false},
+	}
+
+	for _, test := range tests {
+		inverse, ok := test.transform.Invert()
+		if ok != test.invertible {
+			t.Errorf("%s: Invert returned %v, expected %v", test.name, ok, test.invertible)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if product := test.transform.Multiply(inverse); !transformsEqual(product, IdentityTransform()) {
+			t.Errorf("%s: t * t^-1 = %+v, expected identity", test.name, product)
+		}
+		if product := inverse.Multiply(test.transform); !transformsEqual(product, IdentityTransform()) {
+			t.Errorf("%s: t^-1 * t = %+v, expected identity", test.name, product)
+		}
+	}
+}
+
+func TestTransformApplyRect(t *testing.T) {
+	rect := Rect{X: 1, Y: 2, Width: 3, Height: 4}
+
+	tests := []struct {
+		name      string
+		transform Transform
+		want      Rect
+	}{
+		{"identity", IdentityTransform(), rect},
+		{"translate", Translate(10, 20), Rect{X: 11, Y: 22, Width: 3, Height: 4}},
+		{"scale", Scale(2, 3), Rect{X: 2, Y: 6, Width: 6, Height: 12}},
+		{"flip", Scale(-1, -1), Rect{X: -4, Y: -6, Width: 3, Height: 4}},
+		{"rotate 90", Rotate(90), Rect{X: -6, Y: 1, Width: 4, Height: 3}},
+		{"rotate 45", Rotate(45), Rect{
+			X:     float32((1 - 6) / math.Sqrt2),
+			Y:     float32((1 + 2) / math.Sqrt2),
+			Width: float32(7 / math.Sqrt2), Height: float32(7 / math.Sqrt2),
+		}},
+	}
+
+	for _, test := range tests {
+		got := test.transform.ApplyRect(rect)
+		if !floatsEqual(got.X, test.want.X) || !floatsEqual(got.Y, test.want.Y) ||
+			!floatsEqual(got.Width, test.want.Width) || !floatsEqual(got.Height, test.want.Height) {
+			t.Errorf("%s: got %+v, expected %+v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestTransformIsIdentity(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform Transform
+		want      bool
+	}{
+		{"identity", IdentityTransform(), true},
+		{"translate 0", Translate(0, 0), true},
+		{"scale 1", Scale(1, 1), true},
+		{"rotate 0", Rotate(0), true},
+		{"zero value", Transform{}, false},
+		{"translate", Translate(1, 0), false},
+		{"scale", Scale(1, 2), false},
+		{"skew", SkewX(10), false},
+	}
+
+	for _, test := range tests {
+		if got := test.transform.IsIdentity(); got != test.want {
+			t.Errorf("%s: IsIdentity returned %v, expected %v", test.name, got, test.want)
+		}
+	}
+}
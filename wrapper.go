@@ -8,11 +8,19 @@ package resvg
 */
 import "C"
 import (
+	"context"
 	"errors"
 	"fmt"
 	"image"
+	"image/draw"
+	"io/fs"
 	"math"
+	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"unsafe"
 )
 
@@ -24,8 +32,67 @@ var (
 	ErrElementsLimit  = errors.New("elements limit reached")
 	ErrInvalidSize    = errors.New("invalid size")
 	ErrParsingFailed  = errors.New("parsing failed")
+	ErrInvalidBuffer  = errors.New("invalid buffer")
+	ErrClosed         = errors.New("use of closed options or render tree")
+	ErrTooManyPixels  = errors.New("pixel limit exceeded")
+
+	ErrEmptyData         = errors.New("empty data")
+	ErrEmptyImage        = errors.New("SVG contains no renderable elements")
+	ErrNodeNotFound      = errors.New("node not found")
+	ErrInvalidDimensions = errors.New("SVG has invalid dimensions")
+	ErrResourceNotFound  = errors.New("resource not found")
+	ErrResourceBlocked   = errors.New("resource blocked by policy")
+
+	ErrInputTooLarge        = errors.New("input too large")
+	ErrDecompressedTooLarge = errors.New("decompressed input too large")
+	ErrTooDeep              = errors.New("XML nesting too deep")
+	ErrTooManyElements      = errors.New("too many XML elements")
+	ErrEntityExpansion      = errors.New("entity expansion limit exceeded")
 )
 
+// Error records a failed operation, along with the file or node it was working on
+type Error struct {
+	// Op is the operation that failed, such as "parse" or "render node"
+	Op string
+
+	// Path is the file being operated on, if any
+	Path string
+
+	// ID is the ID of the node being operated on, if any
+	ID string
+
+	// Code is the resvg error code, or 0 if the error didn't come from resvg
+	Code int
+
+	// Err is the underlying error, usually one of the Err* values in this package
+	Err error
+}
+
+func (e *Error) Error() string {
+	s := e.Op
+	if e.Path != "" {
+		s += " " + e.Path
+	}
+	if e.ID != "" {
+		s += " '" + e.ID + "'"
+	}
+	return s + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// defaultMaxPixels is the pixel limit used by render trees whose Options don't set one
+var defaultMaxPixels atomic.Uint64
+
+// SetMaxPixels sets the default maximum number of pixels (width * height) a render may produce.
+// It applies to render trees parsed with Options that don't set their own limit, including those
+// used by the convenience render functions. A limit of 0, the default, means no limit.
+func SetMaxPixels(n uint64) {
+	defaultMaxPixels.Store(n)
+}
+
 // ImageRenderingMode represents image rendering quality settings
 type ImageRenderingMode int
 
@@ -52,7 +119,13 @@ const (
 	TextRenderingGeometricPrecision TextRenderingMode = C.RESVG_TEXT_RENDERING_GEOMETRIC_PRECISION
 )
 
-// Transform represents a 2D transformation matrix
+// Transform represents a 2D transformation matrix. The fields form the matrix
+//
+//	| A C E |
+//	| B D F |
+//	| 0 0 1 |
+//
+// which maps the point (x, y) to (A*x + C*y + E, B*x + D*y + F).
 type Transform struct {
 	A, B, C, D, E, F float32
 }
@@ -67,9 +140,25 @@ type Rect struct {
 	X, Y, Width, Height float32
 }
 
-// Options contains configuration for SVG rendering
+// Options contains configuration for SVG rendering. Options are safe for concurrent use: any number of
+// documents can be parsed with the same Options at once, and changing a setting waits for parses in progress.
 type Options struct {
-	cOpts *C.resvg_options
+	refCount
+	settingsMu sync.RWMutex
+	cOpts      *C.resvg_options
+	maxPixels  uint64
+
+	// resourcesDir is the directory set with SetResourcesDir, if any
+	resourcesDir string
+
+	// resolver is the Resolver set with SetResolver, if any
+	resolver Resolver
+
+	// resourcePolicy is the policy set with SetResourcePolicy, if any
+	resourcePolicy *ResourcePolicy
+
+	// limits are the input limits set with SetLimits
+	limits Limits
 }
 
 // NewOptions creates a new Options instance with default settings
@@ -82,7 +171,21 @@ func NewOptions() *Options {
 }
 
 // SetResourcesDir sets the directory for resolving relative paths
-func (o *Options) SetResourcesDir(path string) {
+func (o *Options) SetResourcesDir(path string) error {
+	if err := o.acquire(); err != nil {
+		return err
+	}
+	defer o.release()
+	o.settingsMu.Lock()
+	defer o.settingsMu.Unlock()
+
+	o.resourcesDir = path
+	o.setCResourcesDir(path)
+	return nil
+}
+
+// setCResourcesDir sets the resources directory on the C options. The caller must hold settingsMu for writing.
+func (o *Options) setCResourcesDir(path string) {
 	if path == "" {
 		C.resvg_options_set_resources_dir(o.cOpts, nil)
 		return
@@ -93,103 +196,278 @@ func (o *Options) SetResourcesDir(path string) {
 }
 
 // SetDPI sets the target DPI for unit conversion
-func (o *Options) SetDPI(dpi float32) {
+func (o *Options) SetDPI(dpi float32) error {
+	if err := o.acquire(); err != nil {
+		return err
+	}
+	defer o.release()
+	o.settingsMu.Lock()
+	defer o.settingsMu.Unlock()
+
 	C.resvg_options_set_dpi(o.cOpts, C.float(dpi))
+	return nil
 }
 
 // SetStylesheet sets a CSS stylesheet to use when resolving attributes
-func (o *Options) SetStylesheet(css string) {
+func (o *Options) SetStylesheet(css string) error {
+	if err := o.acquire(); err != nil {
+		return err
+	}
+	defer o.release()
+	o.settingsMu.Lock()
+	defer o.settingsMu.Unlock()
+
 	if css == "" {
 		C.resvg_options_set_stylesheet(o.cOpts, nil)
-		return
+		return nil
 	}
 	cCSS := C.CString(css)
 	defer C.free(unsafe.Pointer(cCSS))
 	C.resvg_options_set_stylesheet(o.cOpts, cCSS)
+	return nil
 }
 
 // SetFontFamily sets the default font family
-func (o *Options) SetFontFamily(family string) {
+func (o *Options) SetFontFamily(family string) error {
+	if err := o.acquire(); err != nil {
+		return err
+	}
+	defer o.release()
+	o.settingsMu.Lock()
+	defer o.settingsMu.Unlock()
+
 	cFamily := C.CString(family)
 	defer C.free(unsafe.Pointer(cFamily))
 	C.resvg_options_set_font_family(o.cOpts, cFamily)
+	return nil
 }
 
 // SetFontSize sets the default font size
-func (o *Options) SetFontSize(size float32) {
+func (o *Options) SetFontSize(size float32) error {
+	if err := o.acquire(); err != nil {
+		return err
+	}
+	defer o.release()
+	o.settingsMu.Lock()
+	defer o.settingsMu.Unlock()
+
 	C.resvg_options_set_font_size(o.cOpts, C.float(size))
+	return nil
 }
 
 // SetSerifFamily sets the serif font family
-func (o *Options) SetSerifFamily(family string) {
+func (o *Options) SetSerifFamily(family string) error {
+	if err := o.acquire(); err != nil {
+		return err
+	}
+	defer o.release()
+	o.settingsMu.Lock()
+	defer o.settingsMu.Unlock()
+
 	cFamily := C.CString(family)
 	defer C.free(unsafe.Pointer(cFamily))
 	C.resvg_options_set_serif_family(o.cOpts, cFamily)
+	return nil
 }
 
 // SetSansSerifFamily sets the sans-serif font family
-func (o *Options) SetSansSerifFamily(family string) {
+func (o *Options) SetSansSerifFamily(family string) error {
+	if err := o.acquire(); err != nil {
+		return err
+	}
+	defer o.release()
+	o.settingsMu.Lock()
+	defer o.settingsMu.Unlock()
+
 	cFamily := C.CString(family)
 	defer C.free(unsafe.Pointer(cFamily))
 	C.resvg_options_set_sans_serif_family(o.cOpts, cFamily)
+	return nil
 }
 
 // SetCursiveFamily sets the cursive font family
-func (o *Options) SetCursiveFamily(family string) {
+func (o *Options) SetCursiveFamily(family string) error {
+	if err := o.acquire(); err != nil {
+		return err
+	}
+	defer o.release()
+	o.settingsMu.Lock()
+	defer o.settingsMu.Unlock()
+
 	cFamily := C.CString(family)
 	defer C.free(unsafe.Pointer(cFamily))
 	C.resvg_options_set_cursive_family(o.cOpts, cFamily)
+	return nil
 }
 
 // SetFantasyFamily sets the fantasy font family
-func (o *Options) SetFantasyFamily(family string) {
+func (o *Options) SetFantasyFamily(family string) error {
+	if err := o.acquire(); err != nil {
+		return err
+	}
+	defer o.release()
+	o.settingsMu.Lock()
+	defer o.settingsMu.Unlock()
+
 	cFamily := C.CString(family)
 	defer C.free(unsafe.Pointer(cFamily))
 	C.resvg_options_set_fantasy_family(o.cOpts, cFamily)
+	return nil
 }
 
 // SetMonospaceFamily sets the monospace font family
-func (o *Options) SetMonospaceFamily(family string) {
+func (o *Options) SetMonospaceFamily(family string) error {
+	if err := o.acquire(); err != nil {
+		return err
+	}
+	defer o.release()
+	o.settingsMu.Lock()
+	defer o.settingsMu.Unlock()
+
 	cFamily := C.CString(family)
 	defer C.free(unsafe.Pointer(cFamily))
 	C.resvg_options_set_monospace_family(o.cOpts, cFamily)
+	return nil
+}
+
+// SetLanguages sets the languages used to resolve the systemLanguage conditional attribute,
+// in order of preference (e.g. "en", "en-US"). Passing an empty list clears the setting.
+func (o *Options) SetLanguages(languages []string) error {
+	if err := o.acquire(); err != nil {
+		return err
+	}
+	defer o.release()
+	o.settingsMu.Lock()
+	defer o.settingsMu.Unlock()
+
+	if len(languages) == 0 {
+		C.resvg_options_set_languages(o.cOpts, nil)
+		return nil
+	}
+	cLanguages := C.CString(strings.Join(languages, ","))
+	defer C.free(unsafe.Pointer(cLanguages))
+	C.resvg_options_set_languages(o.cOpts, cLanguages)
+	return nil
 }
 
 // SetShapeRenderingMode sets the shape rendering method
-func (o *Options) SetShapeRenderingMode(mode ShapeRenderingMode) {
+func (o *Options) SetShapeRenderingMode(mode ShapeRenderingMode) error {
+	if err := o.acquire(); err != nil {
+		return err
+	}
+	defer o.release()
+	o.settingsMu.Lock()
+	defer o.settingsMu.Unlock()
+
 	C.resvg_options_set_shape_rendering_mode(o.cOpts, C.resvg_shape_rendering(mode))
+	return nil
 }
 
 // SetTextRenderingMode sets the text rendering method
-func (o *Options) SetTextRenderingMode(mode TextRenderingMode) {
+func (o *Options) SetTextRenderingMode(mode TextRenderingMode) error {
+	if err := o.acquire(); err != nil {
+		return err
+	}
+	defer o.release()
+	o.settingsMu.Lock()
+	defer o.settingsMu.Unlock()
+
 	C.resvg_options_set_text_rendering_mode(o.cOpts, C.resvg_text_rendering(mode))
+	return nil
 }
 
 // SetImageRenderingMode sets the image rendering method
-func (o *Options) SetImageRenderingMode(mode ImageRenderingMode) {
+func (o *Options) SetImageRenderingMode(mode ImageRenderingMode) error {
+	if err := o.acquire(); err != nil {
+		return err
+	}
+	defer o.release()
+	o.settingsMu.Lock()
+	defer o.settingsMu.Unlock()
+
 	C.resvg_options_set_image_rendering_mode(o.cOpts, C.resvg_image_rendering(mode))
+	return nil
 }
 
 // LoadFontData loads font data into the internal font database
-func (o *Options) LoadFontData(data []byte) {
+func (o *Options) LoadFontData(data []byte) error {
+	if err := o.acquire(); err != nil {
+		return err
+	}
+	defer o.release()
+	o.settingsMu.Lock()
+	defer o.settingsMu.Unlock()
+
 	if len(data) == 0 {
-		return
+		return nil
 	}
 	C.resvg_options_load_font_data(o.cOpts, (*C.char)(unsafe.Pointer(&data[0])), C.uintptr_t(len(data)))
+	return nil
 }
 
 // LoadFontFile loads a font file into the internal font database
 func (o *Options) LoadFontFile(path string) error {
+	if err := o.acquire(); err != nil {
+		return err
+	}
+	defer o.release()
+	o.settingsMu.Lock()
+	defer o.settingsMu.Unlock()
+
 	cPath := C.CString(path)
 	defer C.free(unsafe.Pointer(cPath))
 
 	result := C.resvg_options_load_font_file(o.cOpts, cPath)
-	return cErrorToGoError(result)
+	if err := cErrorToGoError(result); err != nil {
+		return &Error{Op: "load font", Path: path, Code: int(result), Err: err}
+	}
+	return nil
 }
 
 // LoadSystemFonts loads system fonts into the internal font database
-func (o *Options) LoadSystemFonts() {
+func (o *Options) LoadSystemFonts() error {
+	if err := o.acquire(); err != nil {
+		return err
+	}
+	defer o.release()
+	o.settingsMu.Lock()
+	defer o.settingsMu.Unlock()
+
 	C.resvg_options_load_system_fonts(o.cOpts)
+	return nil
+}
+
+// SetMaxPixels sets the maximum number of pixels (width * height) a render of a tree parsed with these
+// options may produce, overriding the package default set with SetMaxPixels. A limit of 0 means the
+// package default is used.
+func (o *Options) SetMaxPixels(n uint64) error {
+	if err := o.acquire(); err != nil {
+		return err
+	}
+	defer o.release()
+	o.settingsMu.Lock()
+	defer o.settingsMu.Unlock()
+
+	o.maxPixels = n
+	return nil
+}
+
+// Close frees the native memory held by the options, including the font database.
+// It is safe to call Close more than once. Other methods return ErrClosed after Close.
+// If a parse using the options is still running in the background, for example after its
+// context was cancelled, the memory is freed once it finishes.
+func (o *Options) Close() error {
+	if o.close() {
+		o.destroy()
+	}
+	runtime.SetFinalizer(o, nil)
+	return nil
+}
+
+func (o *Options) release() {
+	if o.refCount.release() {
+		o.destroy()
+	}
 }
 
 func (o *Options) destroy() {
@@ -199,15 +477,119 @@ func (o *Options) destroy() {
 	}
 }
 
-// RenderTree represents a parsed SVG render tree
+// RenderTree represents a parsed SVG render tree. A RenderTree is safe for concurrent use,
+// including rendering it from several goroutines at once.
 type RenderTree struct {
-	cTree *C.resvg_render_tree
+	refCount
+	cTree     *C.resvg_render_tree
+	maxPixels uint64
 }
 
-// ParseFromData parses SVG data into a render tree
+// ParseFromData parses SVG data into a render tree. Gzip-compressed (.svgz) data is decompressed first.
 func ParseFromData(data []byte, opts *Options) (*RenderTree, error) {
+	return parseTree(context.Background(), data, opts, "", "", nil)
+}
+
+// ParseFromFile parses an SVG or SVGZ file into a render tree. Unless opts sets a resources directory,
+// relative paths in the document are resolved against the directory containing the file.
+func ParseFromFile(path string, opts *Options) (*RenderTree, error) {
+	limits, err := opts.currentLimits()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fileOpenError(path, err)
+	}
+	defer f.Close()
+
+	data, err := readLimited(f, limits.MaxBytes)
+	if errors.Is(err, ErrInputTooLarge) {
+		return nil, &Error{Op: "parse", Path: path, Err: err}
+	} else if err != nil {
+		return nil, fileOpenError(path, err)
+	}
+	return parseTree(context.Background(), data, opts, path, filepath.Dir(path), nil)
+}
+
+// fileOpenError wraps a failure to read the file at path
+func fileOpenError(path string, err error) error {
+	return &Error{
+		Op:   "parse",
+		Path: path,
+		Code: int(C.RESVG_ERROR_FILE_OPEN_FAILED),
+		Err:  fmt.Errorf("%w: %v", ErrFileOpenFailed, err),
+	}
+}
+
+// parseTree decompresses and parses data read from path, which may be empty. If dir is set and opts has no
+// resources directory of its own, relative paths are resolved against dir. If dirFS is set and opts has no
+// Resolver, relative image paths are loaded from dirFS instead. ctx is passed to the Resolver, if it's a
+// ContextResolver.
+func parseTree(ctx context.Context, data []byte, opts *Options, path, dir string, dirFS fs.FS) (*RenderTree, error) {
 	if len(data) == 0 {
-		return nil, errors.New("empty data")
+		return nil, &Error{Op: "parse", Path: path, Err: ErrEmptyData}
+	}
+
+	if err := opts.acquire(); err != nil {
+		return nil, err
+	}
+	defer opts.release()
+
+	opts.settingsMu.RLock()
+	limits, policy, resolver := opts.limits, opts.resourcePolicy, opts.resolver
+	opts.settingsMu.RUnlock()
+
+	if limits.MaxBytes > 0 && int64(len(data)) > limits.MaxBytes {
+		return nil, &Error{Op: "parse", Path: path, Err: inputTooLarge(limits.MaxBytes)}
+	}
+	data, err := decompress(data, limits.MaxDecompressedBytes)
+	if err != nil {
+		parseErr := &Error{Op: "parse", Path: path, Err: err}
+		if errors.Is(err, ErrMalformedGzip) {
+			parseErr.Code = int(C.RESVG_ERROR_MALFORMED_GZIP)
+		}
+		return nil, parseErr
+	}
+	if err := limits.check(data); err != nil {
+		return nil, &Error{Op: "parse", Path: path, Err: err}
+	}
+
+	if policy != nil {
+		if data, err = policy.apply(data); err != nil {
+			return nil, err
+		}
+	}
+	if resolver != nil {
+		if data, err = resolveHrefs(ctx, data, resolver); err != nil {
+			return nil, err
+		}
+	} else if dirFS != nil {
+		if data, err = resolveRelativeHrefs(data, dirFS); err != nil {
+			return nil, err
+		}
+	}
+	if resolver != nil || dirFS != nil {
+		// The resolved images are now embedded in the document, and may be SVG documents themselves
+		if err := limits.check(data); err != nil {
+			return nil, &Error{Op: "parse", Path: path, Err: err}
+		}
+	}
+
+	opts.settingsMu.RLock()
+	if dir != "" && opts.resourcesDir == "" {
+		// The resources directory lives in the shared C options, so it can only be swapped in while no
+		// other parse is using them
+		opts.settingsMu.RUnlock()
+		opts.settingsMu.Lock()
+		defer opts.settingsMu.Unlock()
+		if opts.resourcesDir == "" {
+			opts.setCResourcesDir(dir)
+			defer opts.setCResourcesDir("")
+		}
+	} else {
+		defer opts.settingsMu.RUnlock()
 	}
 
 	var cTree *C.resvg_render_tree
@@ -219,47 +601,42 @@ func ParseFromData(data []byte, opts *Options) (*RenderTree, error) {
 	)
 
 	if err := cErrorToGoError(result); err != nil {
-		return nil, err
+		return nil, &Error{Op: "parse", Path: path, Code: int(result), Err: err}
 	}
 
-	tree := &RenderTree{cTree: cTree}
-	runtime.SetFinalizer(tree, (*RenderTree).destroy)
-	return tree, nil
-}
-
-// ParseFromFile parses an SVG file into a render tree
-func ParseFromFile(path string, opts *Options) (*RenderTree, error) {
-	cPath := C.CString(path)
-	defer C.free(unsafe.Pointer(cPath))
-
-	var cTree *C.resvg_render_tree
-	result := C.resvg_parse_tree_from_file(cPath, opts.cOpts, &cTree)
-
-	if err := cErrorToGoError(result); err != nil {
-		return nil, err
-	}
-
-	tree := &RenderTree{cTree: cTree}
+	tree := &RenderTree{cTree: cTree, maxPixels: opts.maxPixels}
 	runtime.SetFinalizer(tree, (*RenderTree).destroy)
 	return tree, nil
 }
 
 // IsEmpty returns true if the tree has no renderable nodes
-func (t *RenderTree) IsEmpty() bool {
-	return bool(C.resvg_is_image_empty(t.cTree))
+func (t *RenderTree) IsEmpty() (bool, error) {
+	if err := t.acquire(); err != nil {
+		return false, err
+	}
+	defer t.release()
+	return bool(C.resvg_is_image_empty(t.cTree)), nil
 }
 
 // GetImageSize returns the natural size of the SVG
-func (t *RenderTree) GetImageSize() Size {
+func (t *RenderTree) GetImageSize() (Size, error) {
+	if err := t.acquire(); err != nil {
+		return Size{}, err
+	}
+	defer t.release()
 	cSize := C.resvg_get_image_size(t.cTree)
 	return Size{
 		Width:  float32(cSize.width),
 		Height: float32(cSize.height),
-	}
+	}, nil
 }
 
 // GetImageBBox returns the bounding box that contains all SVG elements
-func (t *RenderTree) GetImageBBox() (Rect, bool) {
+func (t *RenderTree) GetImageBBox() (Rect, bool, error) {
+	if err := t.acquire(); err != nil {
+		return Rect{}, false, err
+	}
+	defer t.release()
 	var cRect C.resvg_rect
 	exists := bool(C.resvg_get_image_bbox(t.cTree, &cRect))
 	return Rect{
@@ -267,11 +644,15 @@ func (t *RenderTree) GetImageBBox() (Rect, bool) {
 		Y:      float32(cRect.y),
 		Width:  float32(cRect.width),
 		Height: float32(cRect.height),
-	}, exists
+	}, exists, nil
 }
 
 // GetObjectBBox returns the object bounding box (without stroke and filters)
-func (t *RenderTree) GetObjectBBox() (Rect, bool) {
+func (t *RenderTree) GetObjectBBox() (Rect, bool, error) {
+	if err := t.acquire(); err != nil {
+		return Rect{}, false, err
+	}
+	defer t.release()
 	var cRect C.resvg_rect
 	exists := bool(C.resvg_get_object_bbox(t.cTree, &cRect))
 	return Rect{
@@ -279,75 +660,295 @@ func (t *RenderTree) GetObjectBBox() (Rect, bool) {
 		Y:      float32(cRect.y),
 		Width:  float32(cRect.width),
 		Height: float32(cRect.height),
-	}, exists
+	}, exists, nil
 }
 
-// Render renders the SVG tree to an RGBA image
-func (t *RenderTree) Render(transform Transform, width, height uint32) *image.RGBA {
-	// Create RGBA image
-	img := image.NewRGBA(image.Rect(0, 0, int(width), int(height)))
+// NodeExists returns true if a renderable node with the given ID exists
+func (t *RenderTree) NodeExists(id string) (bool, error) {
+	if err := t.acquire(); err != nil {
+		return false, err
+	}
+	defer t.release()
+	cID := C.CString(id)
+	defer C.free(unsafe.Pointer(cID))
 
-	// Convert transform
-	cTransform := C.resvg_transform{
-		a: C.float(transform.A),
-		b: C.float(transform.B),
-		c: C.float(transform.C),
-		d: C.float(transform.D),
-		e: C.float(transform.E),
-		f: C.float(transform.F),
+	return bool(C.resvg_node_exists(t.cTree, cID)), nil
+}
+
+// NodeTransform returns the transform of the node with the given ID
+func (t *RenderTree) NodeTransform(id string) (Transform, bool, error) {
+	if err := t.acquire(); err != nil {
+		return Transform{}, false, err
 	}
+	defer t.release()
+	cID := C.CString(id)
+	defer C.free(unsafe.Pointer(cID))
 
-	// Render to the image buffer
-	C.resvg_render(
-		t.cTree,
-		cTransform,
-		C.uint32_t(width),
-		C.uint32_t(height),
-		(*C.char)(unsafe.Pointer(&img.Pix[0])),
-	)
+	var cTransform C.resvg_transform
+	exists := bool(C.resvg_get_node_transform(t.cTree, cID, &cTransform))
+	return Transform{
+		A: float32(cTransform.a),
+		B: float32(cTransform.b),
+		C: float32(cTransform.c),
+		D: float32(cTransform.d),
+		E: float32(cTransform.e),
+		F: float32(cTransform.f),
+	}, exists, nil
+}
 
-	// Convert from premultiplied alpha to straight alpha
-	convertFromPremultiplied(img)
+// NodeBBox returns the bounding box of the node with the given ID in canvas coordinates
+func (t *RenderTree) NodeBBox(id string) (Rect, bool, error) {
+	if err := t.acquire(); err != nil {
+		return Rect{}, false, err
+	}
+	defer t.release()
+	cID := C.CString(id)
+	defer C.free(unsafe.Pointer(cID))
 
-	return img
+	var cRect C.resvg_rect
+	exists := bool(C.resvg_get_node_bbox(t.cTree, cID, &cRect))
+	return Rect{
+		X:      float32(cRect.x),
+		Y:      float32(cRect.y),
+		Width:  float32(cRect.width),
+		Height: float32(cRect.height),
+	}, exists, nil
 }
 
-// RenderNode renders a specific node by ID to an RGBA image
-func (t *RenderTree) RenderNode(id string, transform Transform, width, height uint32) (*image.RGBA, error) {
+// NodeStrokeBBox returns the bounding box of the node with the given ID, including stroke, in canvas coordinates
+func (t *RenderTree) NodeStrokeBBox(id string) (Rect, bool, error) {
+	if err := t.acquire(); err != nil {
+		return Rect{}, false, err
+	}
+	defer t.release()
 	cID := C.CString(id)
 	defer C.free(unsafe.Pointer(cID))
 
-	// Create RGBA image
+	var cRect C.resvg_rect
+	exists := bool(C.resvg_get_node_stroke_bbox(t.cTree, cID, &cRect))
+	return Rect{
+		X:      float32(cRect.x),
+		Y:      float32(cRect.y),
+		Width:  float32(cRect.width),
+		Height: float32(cRect.height),
+	}, exists, nil
+}
+
+// Render renders the SVG tree to an RGBA image. The returned pixels are alpha-premultiplied,
+// as required by image.RGBA; use RenderNRGBA to get straight (non-premultiplied) alpha instead.
+func (t *RenderTree) Render(transform Transform, width, height uint32) (*image.RGBA, error) {
+	if err := t.acquire(); err != nil {
+		return nil, err
+	}
+	defer t.release()
+	if err := t.checkSize(width, height); err != nil {
+		return nil, err
+	}
 	img := image.NewRGBA(image.Rect(0, 0, int(width), int(height)))
+	t.render(transform, width, height, img.Pix)
+	return img, nil
+}
 
-	// Convert transform
-	cTransform := C.resvg_transform{
-		a: C.float(transform.A),
-		b: C.float(transform.B),
-		c: C.float(transform.C),
-		d: C.float(transform.D),
-		e: C.float(transform.E),
-		f: C.float(transform.F),
+// RenderNRGBA renders the SVG tree to an NRGBA image with straight (non-premultiplied) alpha
+func (t *RenderTree) RenderNRGBA(transform Transform, width, height uint32) (*image.NRGBA, error) {
+	if err := t.acquire(); err != nil {
+		return nil, err
+	}
+	defer t.release()
+	if err := t.checkSize(width, height); err != nil {
+		return nil, err
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, int(width), int(height)))
+	t.render(transform, width, height, img.Pix)
+
+	// Convert from premultiplied alpha to straight alpha
+	convertFromPremultiplied(img)
+
+	return img, nil
+}
+
+// RenderNode renders a specific node by ID to an RGBA image. The returned pixels are alpha-premultiplied,
+// as required by image.RGBA; use RenderNodeNRGBA to get straight (non-premultiplied) alpha instead.
+func (t *RenderTree) RenderNode(id string, transform Transform, width, height uint32) (*image.RGBA, error) {
+	if err := t.acquire(); err != nil {
+		return nil, err
+	}
+	defer t.release()
+	if err := t.checkSize(width, height); err != nil {
+		return nil, err
+	}
+	img := image.NewRGBA(image.Rect(0, 0, int(width), int(height)))
+	if !t.renderNode(id, transform, width, height, img.Pix) {
+		return nil, &Error{Op: "render node", ID: id, Err: ErrNodeNotFound}
+	}
+	return img, nil
+}
+
+// RenderNodeNRGBA renders a specific node by ID to an NRGBA image with straight (non-premultiplied) alpha
+func (t *RenderTree) RenderNodeNRGBA(id string, transform Transform, width, height uint32) (*image.NRGBA, error) {
+	if err := t.acquire(); err != nil {
+		return nil, err
+	}
+	defer t.release()
+	if err := t.checkSize(width, height); err != nil {
+		return nil, err
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, int(width), int(height)))
+	if !t.renderNode(id, transform, width, height, img.Pix) {
+		return nil, &Error{Op: "render node", ID: id, Err: ErrNodeNotFound}
+	}
+
+	// Convert from premultiplied alpha to straight alpha
+	convertFromPremultiplied(img)
+
+	return img, nil
+}
+
+// RenderInto renders the SVG tree into dst, which must be an *image.RGBA or *image.NRGBA.
+// The whole of dst.Bounds() is rendered to, so sub-images can be used to render into part
+// of a larger image. Existing contents of dst within its bounds are overwritten.
+func (t *RenderTree) RenderInto(dst draw.Image, transform Transform) error {
+	if err := t.acquire(); err != nil {
+		return err
+	}
+	defer t.release()
+	switch d := dst.(type) {
+	case *image.RGBA:
+		width, height := d.Rect.Dx(), d.Rect.Dy()
+		if err := validateBuffer(d.Pix, d.Stride, width, height); err != nil {
+			return err
+		}
+		t.renderStrided(transform, uint32(width), uint32(height), d.Pix, d.Stride)
+	case *image.NRGBA:
+		width, height := d.Rect.Dx(), d.Rect.Dy()
+		if err := validateBuffer(d.Pix, d.Stride, width, height); err != nil {
+			return err
+		}
+		t.renderStrided(transform, uint32(width), uint32(height), d.Pix, d.Stride)
+
+		// Convert from premultiplied alpha to straight alpha
+		convertFromPremultiplied(d)
+	default:
+		return &Error{Op: "render", Err: fmt.Errorf("%w: unsupported image type %T", ErrInvalidBuffer, dst)}
+	}
+	return nil
+}
+
+// RenderToBytes renders the SVG tree into buf as premultiplied RGBA8888 pixels, with stride bytes
+// between the start of each row. Existing contents of the rendered area are overwritten. If stride
+// is more than width*4, the image is rendered into a reused scratch buffer and copied over.
+func (t *RenderTree) RenderToBytes(buf []byte, stride int, width, height uint32, transform Transform) error {
+	if err := t.acquire(); err != nil {
+		return err
+	}
+	defer t.release()
+	if err := validateBuffer(buf, stride, int(width), int(height)); err != nil {
+		return err
+	}
+	t.renderStrided(transform, width, height, buf, stride)
+	return nil
+}
+
+// renderStrided renders the tree into pix, which may have padding between rows
+func (t *RenderTree) renderStrided(transform Transform, width, height uint32, pix []byte, stride int) {
+	rowLen := int(width) * 4
+	if stride == rowLen {
+		pix = pix[:rowLen*int(height)]
+		for i := range pix {
+			pix[i] = 0
+		}
+		t.render(transform, width, height, pix)
+		return
+	}
+
+	// resvg expects tightly packed rows, so render into a pooled scratch buffer and copy each row over
+	scratchPtr := scratchPool.Get().(*[]byte)
+	defer scratchPool.Put(scratchPtr)
+	if cap(*scratchPtr) < rowLen*int(height) {
+		*scratchPtr = make([]byte, rowLen*int(height))
+	}
+
+	scratch := (*scratchPtr)[:rowLen*int(height)]
+	for i := range scratch {
+		scratch[i] = 0
+	}
+	t.render(transform, width, height, scratch)
+	for y := 0; y < int(height); y++ {
+		copy(pix[y*stride:y*stride+rowLen], scratch[y*rowLen:(y+1)*rowLen])
+	}
+}
+
+// scratchPool holds scratch buffers for renderStrided, so that rendering into padded buffers such as
+// sub-images doesn't allocate on every call
+var scratchPool = sync.Pool{
+	New: func() interface{} {
+		return new([]byte)
+	},
+}
+
+// checkSize checks that a width x height image can be allocated and is within the pixel limit
+func (t *RenderTree) checkSize(width, height uint32) error {
+	if width == 0 || height == 0 {
+		return &Error{Op: "render", Err: fmt.Errorf("%w: %dx%d", ErrInvalidSize, width, height)}
+	}
+
+	pixels := uint64(width) * uint64(height)
+	if pixels > math.MaxInt/4 {
+		return &Error{Op: "render", Err: fmt.Errorf("%w: %dx%d", ErrInvalidSize, width, height)}
+	}
+
+	limit := t.maxPixels
+	if limit == 0 {
+		limit = defaultMaxPixels.Load()
 	}
+	if limit != 0 && pixels > limit {
+		return &Error{Op: "render", Err: fmt.Errorf("%w: %dx%d is more than %d pixels", ErrTooManyPixels, width, height, limit)}
+	}
+
+	return nil
+}
+
+// render renders the tree into pix as premultiplied RGBA8888 pixels
+func (t *RenderTree) render(transform Transform, width, height uint32, pix []byte) {
+	C.resvg_render(
+		t.cTree,
+		toCTransform(transform),
+		C.uint32_t(width),
+		C.uint32_t(height),
+		(*C.char)(unsafe.Pointer(&pix[0])),
+	)
+}
 
-	// Render to the image buffer
-	success := bool(C.resvg_render_node(
+// renderNode renders a node into pix as premultiplied RGBA8888 pixels
+func (t *RenderTree) renderNode(id string, transform Transform, width, height uint32, pix []byte) bool {
+	cID := C.CString(id)
+	defer C.free(unsafe.Pointer(cID))
+
+	return bool(C.resvg_render_node(
 		t.cTree,
 		cID,
-		cTransform,
+		toCTransform(transform),
 		C.uint32_t(width),
 		C.uint32_t(height),
-		(*C.char)(unsafe.Pointer(&img.Pix[0])),
+		(*C.char)(unsafe.Pointer(&pix[0])),
 	))
+}
 
-	if !success {
-		return nil, fmt.Errorf("failed to render node with id '%s'", id)
+// Close frees the native memory held by the render tree. It is safe to call Close more than once.
+// Other methods return ErrClosed after Close. If a render of the tree is still running in the
+// background, for example after its context was cancelled, the memory is freed once it finishes.
+func (t *RenderTree) Close() error {
+	if t.close() {
+		t.destroy()
 	}
+	runtime.SetFinalizer(t, nil)
+	return nil
+}
 
-	// Convert from premultiplied alpha to straight alpha
-	convertFromPremultiplied(img)
-
-	return img, nil
+func (t *RenderTree) release() {
+	if t.refCount.release() {
+		t.destroy()
+	}
 }
 
 func (t *RenderTree) destroy() {
@@ -377,102 +978,80 @@ func InitLog() {
 
 // Render is a convenience function that renders SVG data to an RGBA image
 func Render(data []byte) (*image.RGBA, error) {
-	opts := NewOptions()
-	opts.LoadSystemFonts()
-	defer opts.destroy()
-
-	tree, err := ParseFromData(data, opts)
-	if err != nil {
-		return nil, err
-	}
-	defer tree.destroy()
-
-	if tree.IsEmpty() {
-		return nil, errors.New("SVG contains no renderable elements")
-	}
-
-	size := tree.GetImageSize()
-	if size.Width <= 0 || size.Height <= 0 {
-		return nil, errors.New("SVG has invalid dimensions")
-	}
-
-	return tree.Render(IdentityTransform(), uint32(size.Width), uint32(size.Height)), nil
+	return defaultRenderer.Render(data)
 }
 
 // RenderWithSize renders SVG data to an RGBA image with specified dimensions
 func RenderWithSize(data []byte, width, height uint32) (*image.RGBA, error) {
-	opts := NewOptions()
-	opts.LoadSystemFonts()
-	defer opts.destroy()
-
-	tree, err := ParseFromData(data, opts)
-	if err != nil {
-		return nil, err
-	}
-	defer tree.destroy()
-
-	if tree.IsEmpty() {
-		return nil, errors.New("SVG contains no renderable elements")
-	}
-
-	return tree.Render(IdentityTransform(), width, height), nil
+	return defaultRenderer.RenderWithSize(data, width, height)
 }
 
 // RenderScaledToSize renders SVG data to an RGBA image, scaling the content to fit the specified dimensions
 // while preserving aspect ratio and centering it on the canvas. If the natural aspect ratio doesn't match
-// the target, the content will be letterboxed (black bars on sides/top/bottom).
+// the target, the content will be letterboxed, leaving the rest of the canvas transparent.
 func RenderScaledToSize(data []byte, width, height uint32) (*image.RGBA, error) {
-	opts := NewOptions()
-	opts.LoadSystemFonts()
-	defer opts.destroy()
+	return defaultRenderer.RenderScaledToSize(data, width, height)
+}
 
-	tree, err := ParseFromData(data, opts)
-	if err != nil {
-		return nil, err
-	}
-	defer tree.destroy()
+// RenderFit renders SVG data to an RGBA image with the specified dimensions, scaling and aligning the
+// content according to fit
+func RenderFit(data []byte, width, height uint32, fit FitOptions) (*image.RGBA, error) {
+	return defaultRenderer.RenderFit(data, width, height, fit)
+}
 
-	if tree.IsEmpty() {
-		return nil, errors.New("SVG contains no renderable elements")
-	}
+// Helper functions
 
-	naturalSize := tree.GetImageSize()
-	naturalW := float64(naturalSize.Width)
-	naturalH := float64(naturalSize.Height)
-	if naturalW <= 0 || naturalH <= 0 {
-		return nil, errors.New("SVG has invalid natural dimensions")
-	}
+// refCount tracks calls in progress on a native object, so that it is only destroyed once it's
+// closed and no longer in use
+type refCount struct {
+	mu     sync.Mutex
+	closed bool
+	refs   int
+}
 
-	targetW := float64(width)
-	targetH := float64(height)
+// acquire marks the object as in use, failing if it has been closed
+func (r *refCount) acquire() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	// Compute uniform scale to fit (preserve aspect ratio)
-	scaleX := targetW / naturalW
-	scaleY := targetH / naturalH
-	scale := math.Min(scaleX, scaleY)
+	if r.closed {
+		return ErrClosed
+	}
+	r.refs++
+	return nil
+}
 
-	// Scaled dimensions
-	scaledW := naturalW * scale
-	scaledH := naturalH * scale
+// release marks a use of the object as finished, returning true if it should now be destroyed
+func (r *refCount) release() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	// Center offsets
-	tx := (targetW - scaledW) / 2.0
-	ty := (targetH - scaledH) / 2.0
+	r.refs--
+	return r.closed && r.refs == 0
+}
 
-	// Build the transform: scale uniformly, then translate to center
-	transform := Transform{
-		A: float32(scale), // Scale X
-		D: float32(scale), // Scale Y
-		E: float32(tx),    // Translate X
-		F: float32(ty),    // Translate Y
-		B: 0,              // Skew X
-		C: 0,              // Skew Y
-	}
+// close marks the object as closed, returning true if it should now be destroyed
+func (r *refCount) close() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	return tree.Render(transform, width, height), nil
+	if r.closed {
+		return false
+	}
+	r.closed = true
+	return r.refs == 0
 }
 
-// Helper functions
+func toCTransform(transform Transform) C.resvg_transform {
+	return C.resvg_transform{
+		a: C.float(transform.A),
+		b: C.float(transform.B),
+		c: C.float(transform.C),
+		d: C.float(transform.D),
+		e: C.float(transform.E),
+		f: C.float(transform.F),
+	}
+}
 
 func cErrorToGoError(result C.int32_t) error {
 	switch result {
@@ -495,8 +1074,22 @@ func cErrorToGoError(result C.int32_t) error {
 	}
 }
 
-// convertFromPremultiplied converts premultiplied RGBA to straight RGBA
-func convertFromPremultiplied(img *image.RGBA) {
+// validateBuffer checks that pix can hold height rows of width RGBA8888 pixels, stride bytes apart
+func validateBuffer(pix []byte, stride, width, height int) error {
+	if width <= 0 || height <= 0 {
+		return &Error{Op: "render", Err: fmt.Errorf("%w: empty dimensions %dx%d", ErrInvalidBuffer, width, height)}
+	}
+	if stride < width*4 {
+		return &Error{Op: "render", Err: fmt.Errorf("%w: stride %d is too small for width %d", ErrInvalidBuffer, stride, width)}
+	}
+	if needed := (height-1)*stride + width*4; len(pix) < needed {
+		return &Error{Op: "render", Err: fmt.Errorf("%w: buffer has %d bytes, need %d", ErrInvalidBuffer, len(pix), needed)}
+	}
+	return nil
+}
+
+// convertFromPremultiplied converts premultiplied pixels, as written by resvg, to straight alpha in place
+func convertFromPremultiplied(img *image.NRGBA) {
 	bounds := img.Bounds()
 	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
 		for x := bounds.Min.X; x < bounds.Max.X; x++ {
@@ -1,7 +1,12 @@
 package resvg
 
 import (
+	"errors"
 	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"math"
 	"testing"
 )
 
@@ -73,17 +78,27 @@ func TestParseFromData(t *testing.T) {
 		t.Fatal("ParseFromData returned nil tree")
 	}
 
-	if tree.IsEmpty() {
+	empty, err := tree.IsEmpty()
+	if err != nil {
+		t.Fatalf("IsEmpty failed: %v", err)
+	}
+	if empty {
 		t.Fatal("Tree should not be empty")
 	}
 
-	size := tree.GetImageSize()
+	size, err := tree.GetImageSize()
+	if err != nil {
+		t.Fatalf("GetImageSize failed: %v", err)
+	}
 	if size.Width != 50.0 || size.Height != 50.0 {
 		t.Fatalf("Expected size 50x50, got %.1fx%.1f", size.Width, size.Height)
 	}
 
 	// Test rendering
-	img := tree.Render(IdentityTransform(), 50, 50)
+	img, err := tree.Render(IdentityTransform(), 50, 50)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
 	if img == nil {
 		t.Fatal("Render returned nil image")
 	}
@@ -101,6 +116,19 @@ func TestInvalidSVG(t *testing.T) {
 	if err == nil {
 		t.Fatal("Expected error for empty data")
 	}
+
+	// Test with truncated documents
+	for _, data := range []string{"<svg", `<svg xmlns="http://www.w3.org/2000/svg"><rect`, "\xff\xfe\x00<"} {
+		if _, err := Render([]byte(data)); err == nil {
+			t.Fatalf("Expected error for %q", data)
+		}
+	}
+
+	// Test with a gzip header and nothing else
+	_, err = Render([]byte("\x1f\x8b"))
+	if !errors.Is(err, ErrMalformedGzip) {
+		t.Fatalf("Expected ErrMalformedGzip, got %v", err)
+	}
 }
 
 func TestTransform(t *testing.T) {
@@ -125,7 +153,10 @@ func TestBoundingBox(t *testing.T) {
 	}
 
 	// Test object bounding box
-	objBBox, exists := tree.GetObjectBBox()
+	objBBox, exists, err := tree.GetObjectBBox()
+	if err != nil {
+		t.Fatalf("GetObjectBBox failed: %v", err)
+	}
 	if !exists {
 		t.Fatal("Object bounding box should exist")
 	}
@@ -136,12 +167,119 @@ func TestBoundingBox(t *testing.T) {
 	}
 
 	// Test image bounding box
-	_, exists = tree.GetImageBBox()
+	_, exists, err = tree.GetImageBBox()
+	if err != nil {
+		t.Fatalf("GetImageBBox failed: %v", err)
+	}
 	if !exists {
 		t.Fatal("Image bounding box should exist")
 	}
 }
 
+func TestNodeQueries(t *testing.T) {
+	svgData := []byte(`<svg width="100" height="100" xmlns="http://www.w3.org/2000/svg">
+		<g id="group" transform="translate(10 20)">
+			<rect id="box" x="10" y="10" width="30" height="40" fill="blue" stroke="black" stroke-width="4"/>
+		</g>
+	</svg>`)
+
+	opts := NewOptions()
+	tree, err := ParseFromData(svgData, opts)
+	if err != nil {
+		t.Fatalf("ParseFromData failed: %v", err)
+	}
+
+	if exists, _ := tree.NodeExists("box"); !exists {
+		t.Fatal("Node 'box' should exist")
+	}
+	if exists, _ := tree.NodeExists("missing"); exists {
+		t.Fatal("Node 'missing' should not exist")
+	}
+
+	transform, exists, err := tree.NodeTransform("group")
+	if err != nil {
+		t.Fatalf("NodeTransform failed: %v", err)
+	}
+	if !exists {
+		t.Fatal("Transform for node 'group' should exist")
+	}
+	if transform.E != 10.0 || transform.F != 20.0 {
+		t.Fatalf("Node transform incorrect: E=%f, F=%f", transform.E, transform.F)
+	}
+
+	bbox, exists, err := tree.NodeBBox("box")
+	if err != nil {
+		t.Fatalf("NodeBBox failed: %v", err)
+	}
+	if !exists {
+		t.Fatal("Bounding box for node 'box' should exist")
+	}
+	if bbox.X != 20.0 || bbox.Y != 30.0 || bbox.Width != 30.0 || bbox.Height != 40.0 {
+		t.Fatalf("Node bbox incorrect: (%.1f,%.1f) %.1fx%.1f",
+			bbox.X, bbox.Y, bbox.Width, bbox.Height)
+	}
+
+	strokeBBox, exists, err := tree.NodeStrokeBBox("box")
+	if err != nil {
+		t.Fatalf("NodeStrokeBBox failed: %v", err)
+	}
+	if !exists {
+		t.Fatal("Stroke bounding box for node 'box' should exist")
+	}
+	if strokeBBox.X != 18.0 || strokeBBox.Y != 28.0 || strokeBBox.Width != 34.0 || strokeBBox.Height != 44.0 {
+		t.Fatalf("Node stroke bbox incorrect: (%.1f,%.1f) %.1fx%.1f",
+			strokeBBox.X, strokeBBox.Y, strokeBBox.Width, strokeBBox.Height)
+	}
+
+	if _, exists, _ := tree.NodeBBox("missing"); exists {
+		t.Fatal("Bounding box for node 'missing' should not exist")
+	}
+}
+
+func TestSetLanguages(t *testing.T) {
+	svgData := []byte(`<svg width="10" height="10" xmlns="http://www.w3.org/2000/svg">
+		<switch>
+			<rect id="de" systemLanguage="de" width="10" height="10" fill="red"/>
+			<rect id="fr" systemLanguage="fr" width="10" height="10" fill="green"/>
+			<rect id="fallback" width="10" height="10" fill="blue"/>
+		</switch>
+	</svg>`)
+
+	tests := []struct {
+		languages []string
+		expected  string
+	}{
+		{[]string{"de"}, "de"},
+		{[]string{"fr"}, "fr"},
+		{[]string{"es", "fr"}, "fr"},
+		{[]string{"en"}, "fallback"},
+		{nil, "fallback"},
+	}
+
+	for _, test := range tests {
+		opts := NewOptions()
+		opts.SetLanguages(test.languages)
+
+		tree, err := ParseFromData(svgData, opts)
+		if err != nil {
+			t.Fatalf("ParseFromData failed: %v", err)
+		}
+
+		for _, id := range []string{"de", "fr", "fallback"} {
+			exists, err := tree.NodeExists(id)
+			if err != nil {
+				t.Fatalf("NodeExists failed: %v", err)
+			}
+			if id == test.expected && !exists {
+				t.Fatalf("Languages %v: expected node '%s' to be selected", test.languages, id)
+			}
+			if id != test.expected && exists {
+				t.Fatalf("Languages %v: node '%s' should not be selected", test.languages, id)
+			}
+		}
+	}
+}
+
 func TestColorChannels(t *testing.T) {
 	// Test rendering with known colors
 	svgData := []byte(`<svg width="2" height="2" xmlns="http://www.w3.org/2000/svg">
@@ -156,9 +294,8 @@ func TestColorChannels(t *testing.T) {
 		t.Fatalf("Render failed: %v", err)
 	}
 
-	// Check that we have an RGBA image
-	if _, ok := img.(*image.RGBA); !ok {
-		t.Fatal("Expected RGBA image")
+	if img == nil {
+		t.Fatal("Render returned nil image")
 	}
 
 	// Basic sanity check - image should have some non-zero pixels
@@ -181,3 +318,394 @@ func TestColorChannels(t *testing.T) {
 		t.Fatal("Rendered image appears to be completely transparent/black")
 	}
 }
+
+func TestAlphaCompositing(t *testing.T) {
+	svgData := []byte(`<svg width="2" height="2" xmlns="http://www.w3.org/2000/svg">
+		<rect width="2" height="2" fill="red" fill-opacity="0.5"/>
+	</svg>`)
+
+	opts := NewOptions()
+	tree, err := ParseFromData(svgData, opts)
+	if err != nil {
+		t.Fatalf("ParseFromData failed: %v", err)
+	}
+
+	// The premultiplied image should contain resvg's output untouched
+	rgba, err := tree.Render(IdentityTransform(), 2, 2)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if got := rgba.RGBAAt(0, 0); got != (color.RGBA{128, 0, 0, 128}) {
+		t.Fatalf("Premultiplied pixel incorrect: %v", got)
+	}
+
+	// The straight alpha image should have full intensity color channels
+	nrgba, err := tree.RenderNRGBA(IdentityTransform(), 2, 2)
+	if err != nil {
+		t.Fatalf("RenderNRGBA failed: %v", err)
+	}
+	if got := nrgba.NRGBAAt(0, 0); got != (color.NRGBA{255, 0, 0, 128}) {
+		t.Fatalf("Straight alpha pixel incorrect: %v", got)
+	}
+
+	// Both should composite identically over an opaque white background
+	expected := color.RGBA{255, 127, 127, 255}
+	for name, src := range map[string]image.Image{"RGBA": rgba, "NRGBA": nrgba} {
+		dst := image.NewRGBA(image.Rect(0, 0, 2, 2))
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+		draw.Draw(dst, dst.Bounds(), src, image.Point{}, draw.Over)
+
+		if got := dst.RGBAAt(1, 1); got != expected {
+			t.Fatalf("%s composited pixel incorrect: expected %v, got %v", name, expected, got)
+		}
+	}
+}
+
+func TestRenderNodeNRGBA(t *testing.T) {
+	svgData := []byte(`<svg width="2" height="2" xmlns="http://www.w3.org/2000/svg">
+		<rect id="box" width="2" height="2" fill="blue" fill-opacity="0.5"/>
+	</svg>`)
+
+	opts := NewOptions()
+	tree, err := ParseFromData(svgData, opts)
+	if err != nil {
+		t.Fatalf("ParseFromData failed: %v", err)
+	}
+
+	img, err := tree.RenderNodeNRGBA("box", IdentityTransform(), 2, 2)
+	if err != nil {
+		t.Fatalf("RenderNodeNRGBA failed: %v", err)
+	}
+
+	if got := img.NRGBAAt(0, 0); got != (color.NRGBA{0, 0, 255, 128}) {
+		t.Fatalf("Straight alpha pixel incorrect: %v", got)
+	}
+
+	if _, err := tree.RenderNodeNRGBA("missing", IdentityTransform(), 2, 2); err == nil {
+		t.Fatal("Expected error for missing node")
+	}
+}
+
+func TestRenderInto(t *testing.T) {
+	svgData := []byte(`<svg width="2" height="2" xmlns="http://www.w3.org/2000/svg">
+		<rect width="2" height="2" fill="blue" fill-opacity="0.5"/>
+	</svg>`)
+
+	opts := NewOptions()
+	tree, err := ParseFromData(svgData, opts)
+	if err != nil {
+		t.Fatalf("ParseFromData failed: %v", err)
+	}
+
+	// Render twice into the same buffer, the second render should not composite over the first
+	rgba := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	for i := 0; i < 2; i++ {
+		if err := tree.RenderInto(rgba, IdentityTransform()); err != nil {
+			t.Fatalf("RenderInto failed: %v", err)
+		}
+		if got := rgba.RGBAAt(1, 1); got != (color.RGBA{0, 0, 128, 128}) {
+			t.Fatalf("Render %d: pixel incorrect: %v", i, got)
+		}
+	}
+
+	nrgba := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	if err := tree.RenderInto(nrgba, IdentityTransform()); err != nil {
+		t.Fatalf("RenderInto failed: %v", err)
+	}
+	if got := nrgba.NRGBAAt(1, 1); got != (color.NRGBA{0, 0, 255, 128}) {
+		t.Fatalf("Straight alpha pixel incorrect: %v", got)
+	}
+
+	if err := tree.RenderInto(image.NewGray(image.Rect(0, 0, 2, 2)), IdentityTransform()); !errors.Is(err, ErrInvalidBuffer) {
+		t.Fatalf("Expected ErrInvalidBuffer for unsupported image type, got %v", err)
+	}
+	if err := tree.RenderInto(image.NewRGBA(image.Rectangle{}), IdentityTransform()); !errors.Is(err, ErrInvalidBuffer) {
+		t.Fatalf("Expected ErrInvalidBuffer for empty image, got %v", err)
+	}
+}
+
+func TestRenderIntoSubImage(t *testing.T) {
+	svgData := []byte(`<svg width="2" height="2" xmlns="http://www.w3.org/2000/svg">
+		<rect width="2" height="2" fill="red"/>
+	</svg>`)
+
+	opts := NewOptions()
+	tree, err := ParseFromData(svgData, opts)
+	if err != nil {
+		t.Fatalf("ParseFromData failed: %v", err)
+	}
+
+	white := color.RGBA{255, 255, 255, 255}
+	red := color.RGBA{255, 0, 0, 255}
+
+	canvas := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(white), image.Point{}, draw.Src)
+
+	sub := canvas.SubImage(image.Rect(1, 2, 3, 4)).(*image.RGBA)
+	if err := tree.RenderInto(sub, IdentityTransform()); err != nil {
+		t.Fatalf("RenderInto failed: %v", err)
+	}
+
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			expected := white
+			if image.Pt(x, y).In(sub.Rect) {
+				expected = red
+			}
+			if got := canvas.RGBAAt(x, y); got != expected {
+				t.Fatalf("Pixel (%d,%d) incorrect: expected %v, got %v", x, y, expected, got)
+			}
+		}
+	}
+}
+
+// blurTestSVG has blurs and a drop shadow spread across the whole image, which only come out right if the
+// image is rendered in one piece
+const blurTestSVG = `<svg width="100" height="60" xmlns="http://www.w3.org/2000/svg">
+	<filter id="blur"><feGaussianBlur stdDeviation="2.5"/></filter>
+	<filter id="shadow"><feDropShadow dx="1" dy="4" stdDeviation="3" flood-color="black"/></filter>
+	<circle cx="40" cy="30" r="23.3" fill="red" filter="url(#blur)"/>
+	<rect x="55.5" y="8.25" width="30" height="40" fill="blue" filter="url(#shadow)"/>
+</svg>`
+
+func TestRenderIntoSubImageScratch(t *testing.T) {
+	opts := NewOptions()
+	tree, err := ParseFromData([]byte(blurTestSVG), opts)
+	if err != nil {
+		t.Fatalf("ParseFromData failed: %v", err)
+	}
+
+	// A large, blurred sub-image must render exactly as Render does
+	transform := Scale(10, 2.5)
+	canvas := image.NewRGBA(image.Rect(0, 0, 1100, 200))
+	sub := canvas.SubImage(image.Rect(50, 20, 1050, 170)).(*image.RGBA)
+	if err := tree.RenderInto(sub, transform); err != nil {
+		t.Fatalf("RenderInto failed: %v", err)
+	}
+
+	full, err := tree.Render(transform, 1000, 150)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	for y := 0; y < 150; y++ {
+		for x := 0; x < 1000; x++ {
+			if got, want := sub.RGBAAt(50+x, 20+y), full.RGBAAt(x, y); got != want {
+				t.Fatalf("Pixel (%d,%d) incorrect: expected %v, got %v", x, y, want, got)
+			}
+		}
+	}
+
+	// The scratch buffer is reused, so rendering into a sub-image shouldn't allocate
+	allocs := testing.AllocsPerRun(20, func() {
+		if err := tree.RenderInto(sub, transform); err != nil {
+			t.Fatalf("RenderInto failed: %v", err)
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("Expected no allocations per RenderInto, got %v", allocs)
+	}
+}
+
+func TestRenderToBytes(t *testing.T) {
+	svgData := []byte(`<svg width="2" height="2" xmlns="http://www.w3.org/2000/svg">
+		<rect width="2" height="2" fill="lime"/>
+	</svg>`)
+
+	opts := NewOptions()
+	tree, err := ParseFromData(svgData, opts)
+	if err != nil {
+		t.Fatalf("ParseFromData failed: %v", err)
+	}
+
+	// Use a stride with 4 bytes of padding per row, the padding should be left untouched
+	const stride = 12
+	buf := make([]byte, stride*2)
+	for i := range buf {
+		buf[i] = 0xaa
+	}
+
+	if err := tree.RenderToBytes(buf, stride, 2, 2, IdentityTransform()); err != nil {
+		t.Fatalf("RenderToBytes failed: %v", err)
+	}
+
+	for y := 0; y < 2; y++ {
+		row := buf[y*stride : (y+1)*stride]
+		for x := 0; x < 2; x++ {
+			if got := row[x*4 : x*4+4]; got[0] != 0 || got[1] != 255 || got[2] != 0 || got[3] != 255 {
+				t.Fatalf("Pixel (%d,%d) incorrect: %v", x, y, got)
+			}
+		}
+		for _, b := range row[8:] {
+			if b != 0xaa {
+				t.Fatalf("Row %d padding was overwritten", y)
+			}
+		}
+	}
+
+	if err := tree.RenderToBytes(buf, 4, 2, 2, IdentityTransform()); !errors.Is(err, ErrInvalidBuffer) {
+		t.Fatalf("Expected ErrInvalidBuffer for small stride, got %v", err)
+	}
+	if err := tree.RenderToBytes(buf[:stride+4], stride, 2, 2, IdentityTransform()); !errors.Is(err, ErrInvalidBuffer) {
+		t.Fatalf("Expected ErrInvalidBuffer for short buffer, got %v", err)
+	}
+}
+
+func TestClose(t *testing.T) {
+	svgData := []byte(`<svg width="10" height="10" xmlns="http://www.w3.org/2000/svg">
+		<rect id="box" width="10" height="10" fill="red"/>
+	</svg>`)
+
+	opts := NewOptions()
+	tree, err := ParseFromData(svgData, opts)
+	if err != nil {
+		t.Fatalf("ParseFromData failed: %v", err)
+	}
+
+	var _ io.Closer = opts
+	var _ io.Closer = tree
+
+	// Closing more than once should be safe
+	for i := 0; i < 2; i++ {
+		if err := tree.Close(); err != nil {
+			t.Fatalf("RenderTree.Close failed: %v", err)
+		}
+		if err := opts.Close(); err != nil {
+			t.Fatalf("Options.Close failed: %v", err)
+		}
+	}
+
+	if err := opts.SetDPI(96.0); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Expected ErrClosed from SetDPI, got %v", err)
+	}
+	if err := opts.LoadSystemFonts(); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Expected ErrClosed from LoadSystemFonts, got %v", err)
+	}
+	if _, err := ParseFromData(svgData, opts); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Expected ErrClosed from ParseFromData, got %v", err)
+	}
+
+	if _, err := tree.GetImageSize(); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Expected ErrClosed from GetImageSize, got %v", err)
+	}
+	if _, _, err := tree.NodeBBox("box"); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Expected ErrClosed from NodeBBox, got %v", err)
+	}
+	if _, err := tree.Render(IdentityTransform(), 10, 10); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Expected ErrClosed from Render, got %v", err)
+	}
+	if _, err := tree.RenderNode("box", IdentityTransform(), 10, 10); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Expected ErrClosed from RenderNode, got %v", err)
+	}
+	if err := tree.RenderInto(image.NewRGBA(image.Rect(0, 0, 10, 10)), IdentityTransform()); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Expected ErrClosed from RenderInto, got %v", err)
+	}
+}
+
+func TestInvalidDimensions(t *testing.T) {
+	svgData := []byte(`<svg width="10" height="10" xmlns="http://www.w3.org/2000/svg">
+		<rect id="box" width="10" height="10" fill="red"/>
+	</svg>`)
+
+	opts := NewOptions()
+	tree, err := ParseFromData(svgData, opts)
+	if err != nil {
+		t.Fatalf("ParseFromData failed: %v", err)
+	}
+
+	sizes := []struct {
+		width, height uint32
+	}{
+		{0, 10},
+		{10, 0},
+		{0, 0},
+		{math.MaxUint32, math.MaxUint32},
+	}
+
+	for _, size := range sizes {
+		if _, err := tree.Render(IdentityTransform(), size.width, size.height); !errors.Is(err, ErrInvalidSize) {
+			t.Fatalf("Render %dx%d: expected ErrInvalidSize, got %v", size.width, size.height, err)
+		}
+		if _, err := tree.RenderNode("box", IdentityTransform(), size.width, size.height); !errors.Is(err, ErrInvalidSize) {
+			t.Fatalf("RenderNode %dx%d: expected ErrInvalidSize, got %v", size.width, size.height, err)
+		}
+		if _, err := RenderWithSize(svgData, size.width, size.height); !errors.Is(err, ErrInvalidSize) {
+			t.Fatalf("RenderWithSize %dx%d: expected ErrInvalidSize, got %v", size.width, size.height, err)
+		}
+		if _, err := RenderScaledToSize(svgData, size.width, size.height); !errors.Is(err, ErrInvalidSize) {
+			t.Fatalf("RenderScaledToSize %dx%d: expected ErrInvalidSize, got %v", size.width, size.height, err)
+		}
+	}
+}
+
+func TestMaxPixels(t *testing.T) {
+	svgData := []byte(`<svg width="10" height="10" xmlns="http://www.w3.org/2000/svg">
+		<rect width="10" height="10" fill="red"/>
+	</svg>`)
+
+	opts := NewOptions()
+	opts.SetMaxPixels(100)
+	tree, err := ParseFromData(svgData, opts)
+	if err != nil {
+		t.Fatalf("ParseFromData failed: %v", err)
+	}
+
+	if _, err := tree.Render(IdentityTransform(), 10, 10); err != nil {
+		t.Fatalf("Render within limit failed: %v", err)
+	}
+	if _, err := tree.Render(IdentityTransform(), 60000, 60000); !errors.Is(err, ErrTooManyPixels) {
+		t.Fatalf("Expected ErrTooManyPixels, got %v", err)
+	}
+
+	// The package default should apply to the convenience functions
+	SetMaxPixels(100)
+	defer SetMaxPixels(0)
+
+	if _, err := RenderWithSize(svgData, 10, 10); err != nil {
+		t.Fatalf("RenderWithSize within limit failed: %v", err)
+	}
+	if _, err := RenderWithSize(svgData, 60000, 60000); !errors.Is(err, ErrTooManyPixels) {
+		t.Fatalf("Expected ErrTooManyPixels, got %v", err)
+	}
+}
+
+func TestErrorTypes(t *testing.T) {
+	_, err := ParseFromData(nil, nil)
+	if !errors.Is(err, ErrEmptyData) {
+		t.Fatalf("Expected ErrEmptyData, got %v", err)
+	}
+
+	opts := NewOptions()
+	defer opts.Close()
+
+	_, err = ParseFromFile("testdata/does-not-exist.svg", opts)
+	if !errors.Is(err, ErrFileOpenFailed) {
+		t.Fatalf("Expected ErrFileOpenFailed, got %v", err)
+	}
+
+	var resvgErr *Error
+	if !errors.As(err, &resvgErr) {
+		t.Fatalf("Expected *Error, got %T", err)
+	}
+	if resvgErr.Op != "parse" || resvgErr.Path != "testdata/does-not-exist.svg" || resvgErr.Code == 0 {
+		t.Fatalf("Unexpected error fields: %+v", resvgErr)
+	}
+
+	tree, err := ParseFromData([]byte(`<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"><rect id="r" width="10" height="10"/></svg>`), opts)
+	if err != nil {
+		t.Fatalf("ParseFromData failed: %v", err)
+	}
+	defer tree.Close()
+
+	_, err = tree.RenderNode("missing", IdentityTransform(), 10, 10)
+	if !errors.Is(err, ErrNodeNotFound) {
+		t.Fatalf("Expected ErrNodeNotFound, got %v", err)
+	}
+	if !errors.As(err, &resvgErr) || resvgErr.ID != "missing" {
+		t.Fatalf("Expected *Error with node ID, got %v", err)
+	}
+
+	_, err = Render([]byte(`<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"></svg>`))
+	if !errors.Is(err, ErrEmptyImage) {
+		t.Fatalf("Expected ErrEmptyImage, got %v", err)
+	}
+}